@@ -5,7 +5,11 @@ go 1.15
 require (
 	github.com/golang/protobuf v1.4.3
 	github.com/joho/godotenv v1.3.0
+	github.com/yuin/goldmark v1.4.12
 	go.mongodb.org/mongo-driver v1.4.6
+	golang.org/x/net v0.0.0-20200202094626-16171245cfb2
+	golang.org/x/text v0.3.3
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 	google.golang.org/grpc v1.35.0
 	google.golang.org/protobuf v1.25.0
 )
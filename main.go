@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	pb "example.com/grpc/blog/gen/src"
@@ -31,7 +34,53 @@ func main() {
 		log.Fatalln("Error getting Mongo client", err)
 	}
 	r := repo.NewMongoArticleRepo(c)
-	log.Fatal(serve(r))
+	cr := repo.NewMongoCommentRepo(c)
+	au := repo.NewMongoAuthorRepo(c)
+	if err := ensureIndexes(r, cr); err != nil {
+		log.Fatalln("Error creating Mongo indexes", err)
+	}
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalln("Error parsing TRASH_RETENTION", err)
+		}
+		server.TrashRetention = d
+	}
+	if v := os.Getenv("GRPC_WEB_ORIGINS"); v != "" {
+		server.AllowedOrigins = strings.Split(v, ",")
+	}
+	if v := os.Getenv("FEED_TITLE"); v != "" {
+		server.FeedTitle = v
+	}
+	if v := os.Getenv("TRUST_PROXY"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalln("Error parsing TRUST_PROXY", err)
+		}
+		server.TrustProxy = b
+	}
+	go backfillSummaries(r)
+	go server.RunScheduler(context.Background(), r)
+	go server.RunPurger(context.Background(), r, cr)
+	// HTTP and gRPC share the server, so rendered content is cached once
+	bs := server.NewBlogServer(r, au)
+	if os.Getenv("HTTP_URI") != "" {
+		go func() {
+			log.Fatal(serveHTTP(bs))
+		}()
+	}
+	log.Fatal(serve(bs, r, cr, au))
+}
+
+// backfillSummaries derives summaries of Articles stored before Articles had them
+func backfillSummaries(r *repo.MongoArticleRepo) {
+	n, err := r.BackfillSummaries(context.Background())
+	if err != nil {
+		log.Printf("Error backfilling article summaries: %v", err)
+	}
+	if n > 0 {
+		log.Printf("Backfilled summaries of %v articles", n)
+	}
 }
 
 func initDB() (*mongo.Client, error) {
@@ -44,18 +93,74 @@ func initDB() (*mongo.Client, error) {
 	return client, nil
 }
 
-func serve(r repo.ArticleRepo) error {
+// indexedRepo is implemented by Mongo repos which need indexes
+type indexedRepo interface {
+	EnsureIndexes(context.Context) error
+}
+
+func ensureIndexes(rs ...indexedRepo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(5*time.Second))
+	defer cancel()
+	for _, r := range rs {
+		if err := r.EnsureIndexes(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func serve(bs *server.BlogServer, r repo.ArticleRepo, cr repo.CommentRepo, au repo.AuthorRepo) error {
 	li, err := net.Listen("tcp", os.Getenv("URI"))
 	if err != nil {
 		return err
 	}
 	defer li.Close()
 	s := grpc.NewServer()
-	pb.RegisterBlogServer(s, server.NewBlogServer(r))
+	pb.RegisterBlogServer(s, bs)
+	pb.RegisterAuthorsServer(s, server.NewAuthorsServer(au))
+	pb.RegisterCommentsServer(s, server.NewCommentsServer(cr, r))
 	reflection.Register(s)
+	if os.Getenv("GRPC_WEB_URI") != "" {
+		go func() {
+			log.Fatal(serveGRPCWeb(s))
+		}()
+	}
 	fmt.Println("Listening on", os.Getenv("URI"), "...")
 	if err := s.Serve(li); err != nil {
 		return err
 	}
 	return nil
 }
+
+// serveGRPCWeb serves gRPC-Web of browsers on GRPC_WEB_URI with the services of the gRPC server
+func serveGRPCWeb(s *grpc.Server) error {
+	hs := &http.Server{
+		Addr:              os.Getenv("GRPC_WEB_URI"),
+		Handler:           server.GRPCWebHandler(s),
+		ReadHeaderTimeout: time.Duration(5 * time.Second),
+		ReadTimeout:       time.Duration(30 * time.Second),
+		// there's no write timeout, Watch streams stay open as long as the client needs them
+		IdleTimeout: time.Duration(2 * time.Minute),
+	}
+	fmt.Println("gRPC-Web listening on", os.Getenv("GRPC_WEB_URI"), "...")
+	return hs.ListenAndServe()
+}
+
+// serveHTTP serves RSS and Atom feeds and the REST gateway on HTTP_URI
+func serveHTTP(bs *server.BlogServer) error {
+	mux := http.NewServeMux()
+	mux.Handle("/", bs.FeedHandler())
+	mux.Handle(server.GatewayPrefix, bs.GatewayHandler())
+	mux.Handle(server.GatewayPrefix+"/", bs.GatewayHandler())
+	srv := &http.Server{
+		Addr:              os.Getenv("HTTP_URI"),
+		Handler:           mux,
+		ReadHeaderTimeout: time.Duration(5 * time.Second),
+		ReadTimeout:       time.Duration(10 * time.Second),
+		// responses are bounded by ListTimeout, so it leaves room for writing them out
+		WriteTimeout: server.ListTimeout + time.Duration(10*time.Second),
+		IdleTimeout:  time.Duration(2 * time.Minute),
+	}
+	fmt.Println("HTTP listening on", os.Getenv("HTTP_URI"), "...")
+	return srv.ListenAndServe()
+}
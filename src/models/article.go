@@ -1,8 +1,13 @@
 package models
 
 import (
+	"strings"
+	"time"
+
 	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/render"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Article model represents the article
@@ -11,9 +16,112 @@ type Article struct {
 	AuthorID string             `bson:"author_id"`
 	Title    string             `bson:"title"`
 	Content  string             `bson:"content"`
+	Tags     []string           `bson:"tags"`
+	// ContentFormat is empty for plain text Articles
+	ContentFormat render.Format `bson:"content_format,omitempty"`
+	// Excerpt, WordCount and ReadingMinutes are derived from the content by Summarize
+	Excerpt        string `bson:"excerpt"`
+	WordCount      int64  `bson:"word_count"`
+	ReadingMinutes int64  `bson:"reading_minutes"`
+	// Slug is generated from Title on creation and doesn't change afterwards
+	Slug string `bson:"slug,omitempty"`
+	// ExternalID is the ID in the system the Article was imported from
+	ExternalID string `bson:"external_id,omitempty"`
+	// Status is changed only through publishing RPCs
+	Status Status `bson:"status,omitempty"`
+	// PublishTime is when the Article was or is scheduled to be published
+	PublishTime time.Time `bson:"publish_time,omitempty"`
+
+	// Version is increased by repositories on every change
+	Version int64 `bson:"version"`
+
+	// CreateTime and UpdateTime are maintained by repositories
+	CreateTime time.Time `bson:"create_time"`
+	UpdateTime time.Time `bson:"update_time"`
+	// DeleteTime is set when the Article is moved to trash
+	DeleteTime time.Time `bson:"delete_time,omitempty"`
+}
+
+// IsDeleted checks whether the Article is in trash
+func (m Article) IsDeleted() bool {
+	return !m.DeleteTime.IsZero()
+}
+
+// Article fields which can be updated, named after their bson keys
+const (
+	FieldAuthorID = "author_id"
+	FieldTitle    = "title"
+	FieldContent  = "content"
+	FieldTags     = "tags"
+	// FieldContentFormat goes along with content, but it's updated only when listed explicitly
+	FieldContentFormat = "content_format"
+)
+
+// UpdatableFields lists every Article field which can be updated
+var UpdatableFields = []string{FieldAuthorID, FieldTitle, FieldContent, FieldTags}
+
+// AllFields lists UpdatableFields along with the ones updated only when requested explicitly
+var AllFields = []string{FieldAuthorID, FieldTitle, FieldContent, FieldTags, FieldContentFormat}
+
+// Field returns the value of an updatable field by its name
+func (m Article) Field(name string) (interface{}, bool) {
+	switch name {
+	case FieldAuthorID:
+		return m.AuthorID, true
+	case FieldTitle:
+		return m.Title, true
+	case FieldContent:
+		return m.Content, true
+	case FieldTags:
+		return m.Tags, true
+	case FieldContentFormat:
+		return m.ContentFormat, true
+	}
+	return nil, false
+}
+
+// CopyField copies an updatable field by its name from src
+func (m *Article) CopyField(src Article, name string) bool {
+	switch name {
+	case FieldAuthorID:
+		m.AuthorID = src.AuthorID
+	case FieldTitle:
+		m.Title = src.Title
+	case FieldContent:
+		m.Content = src.Content
+	case FieldTags:
+		m.Tags = src.Tags
+	case FieldContentFormat:
+		m.ContentFormat = src.ContentFormat
+	default:
+		return false
+	}
+	return true
+}
+
+// NormalizeTags lowercases tags and drops empty and duplicated ones keeping the order
+func NormalizeTags(tags []string) []string {
+	res := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		res = append(res, t)
+	}
+	return res
+}
+
+// Now returns current time with the precision MongoDB can store
+func Now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
 }
 
 // FromPB creates Article from Protocol Buffers struct definition
+// Timestamps, slug, status and version are maintained by the server, so they're skipped
+// Tags are kept as requested, they're normalized once validated
 func FromPB(a *pb.Article) (*Article, error) {
 	oid, err := primitive.ObjectIDFromHex(a.GetId())
 	if err != nil {
@@ -25,15 +133,59 @@ func FromPB(a *pb.Article) (*Article, error) {
 		AuthorID: a.GetAuthorId(),
 		Title:    a.GetTitle(),
 		Content:  a.GetContent(),
+		Tags:     a.GetTags(),
+		// unknown formats are left for the server to reject
+		ContentFormat: formatFromPB[a.GetContentFormat()],
 	}, nil
 }
 
 // ToPB converts Article to Protocol Buffer message
 func (m Article) ToPB() *pb.Article {
 	return &pb.Article{
-		Id:       m.ID.Hex(),
-		AuthorId: m.AuthorID,
-		Title:    m.Title,
-		Content:  m.Content,
+		Id:             m.ID.Hex(),
+		AuthorId:       m.AuthorID,
+		Title:          m.Title,
+		Content:        m.Content,
+		Tags:           m.Tags,
+		ContentFormat:  formatToPB[m.ContentFormat],
+		Excerpt:        m.Excerpt,
+		WordCount:      m.WordCount,
+		ReadingMinutes: m.ReadingMinutes,
+		Slug:           m.Slug,
+		ExternalId:     m.ExternalID,
+		Version:        m.Version,
+		Status:         statusToPB[m.CurrentStatus()],
+		PublishTime:    timestampPB(m.PublishTime),
+		CreateTime:     timestampPB(m.CreateTime),
+		UpdateTime:     timestampPB(m.UpdateTime),
+		DeleteTime:     timestampPB(m.DeleteTime),
+	}
+}
+
+var formatToPB = map[render.Format]pb.Article_ContentFormat{
+	"":                    pb.Article_PLAIN,
+	render.FormatPlain:    pb.Article_PLAIN,
+	render.FormatMarkdown: pb.Article_MARKDOWN,
+	render.FormatHTML:     pb.Article_HTML,
+}
+
+var formatFromPB = map[pb.Article_ContentFormat]render.Format{
+	pb.Article_CONTENT_FORMAT_UNSPECIFIED: render.FormatPlain,
+	pb.Article_PLAIN:                      render.FormatPlain,
+	pb.Article_MARKDOWN:                   render.FormatMarkdown,
+	pb.Article_HTML:                       render.FormatHTML,
+}
+
+// FormatFromPB converts Protocol Buffers content format, returns false for unknown ones
+func FormatFromPB(f pb.Article_ContentFormat) (render.Format, bool) {
+	rf, ok := formatFromPB[f]
+	return rf, ok
+}
+
+// timestampPB converts time to Timestamp, zero time is left unset
+func timestampPB(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
 	}
+	return timestamppb.New(t)
 }
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Author model represents the profile of an Article author
+type Author struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	DisplayName string             `bson:"display_name"`
+	Bio         string             `bson:"bio"`
+	AvatarURL   string             `bson:"avatar_url"`
+	Email       string             `bson:"email"`
+
+	// CreateTime and UpdateTime are maintained by repositories
+	CreateTime time.Time `bson:"create_time"`
+	UpdateTime time.Time `bson:"update_time"`
+}
+
+// Author fields which can be updated, named after their bson keys
+const (
+	FieldDisplayName = "display_name"
+	FieldBio         = "bio"
+	FieldAvatarURL   = "avatar_url"
+	FieldEmail       = "email"
+)
+
+// AuthorFields lists every Author field which can be updated
+var AuthorFields = []string{FieldDisplayName, FieldBio, FieldAvatarURL, FieldEmail}
+
+// Field returns the value of an updatable field by its name
+func (m Author) Field(name string) (interface{}, bool) {
+	switch name {
+	case FieldDisplayName:
+		return m.DisplayName, true
+	case FieldBio:
+		return m.Bio, true
+	case FieldAvatarURL:
+		return m.AvatarURL, true
+	case FieldEmail:
+		return m.Email, true
+	}
+	return nil, false
+}
+
+// CopyField copies an updatable field by its name from src
+func (m *Author) CopyField(src Author, name string) bool {
+	switch name {
+	case FieldDisplayName:
+		m.DisplayName = src.DisplayName
+	case FieldBio:
+		m.Bio = src.Bio
+	case FieldAvatarURL:
+		m.AvatarURL = src.AvatarURL
+	case FieldEmail:
+		m.Email = src.Email
+	default:
+		return false
+	}
+	return true
+}
+
+// AuthorFromPB creates Author from Protocol Buffers struct definition
+// Timestamps are maintained by the server, so they're skipped
+func AuthorFromPB(a *pb.Author) (*Author, error) {
+	oid, err := primitive.ObjectIDFromHex(a.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &Author{
+		ID:          oid,
+		DisplayName: a.GetDisplayName(),
+		Bio:         a.GetBio(),
+		AvatarURL:   a.GetAvatarUrl(),
+		Email:       a.GetEmail(),
+	}, nil
+}
+
+// ToPB converts Author to Protocol Buffer message
+func (m Author) ToPB() *pb.Author {
+	return &pb.Author{
+		Id:          m.ID.Hex(),
+		DisplayName: m.DisplayName,
+		Bio:         m.Bio,
+		AvatarUrl:   m.AvatarURL,
+		Email:       m.Email,
+		CreateTime:  timestampPB(m.CreateTime),
+		UpdateTime:  timestampPB(m.UpdateTime),
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Comment model represents a comment of an Article
+type Comment struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ArticleID primitive.ObjectID `bson:"article_id"`
+	// ParentID is the Comment being replied to, it's zero for top level Comments
+	ParentID primitive.ObjectID `bson:"parent_id,omitempty"`
+	// Ancestors lists IDs of the thread from the top level Comment down to the parent
+	Ancestors []primitive.ObjectID `bson:"ancestors,omitempty"`
+	AuthorID  string               `bson:"author_id"`
+	Content   string               `bson:"content"`
+
+	// CreateTime is maintained by repositories
+	CreateTime time.Time `bson:"create_time"`
+}
+
+// ReplyTo makes the Comment a reply to the parent one
+func (m *Comment) ReplyTo(parent Comment) {
+	m.ParentID = parent.ID
+	m.Ancestors = append(append([]primitive.ObjectID{}, parent.Ancestors...), parent.ID)
+}
+
+// HasAncestor checks whether the Comment is a reply to the one by ID, directly or not
+func (m Comment) HasAncestor(id primitive.ObjectID) bool {
+	for _, a := range m.Ancestors {
+		if a == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ToPB converts Comment to Protocol Buffer message
+func (m Comment) ToPB() *pb.Comment {
+	c := &pb.Comment{
+		Id:         m.ID.Hex(),
+		ArticleId:  m.ArticleID.Hex(),
+		AuthorId:   m.AuthorID,
+		Content:    m.Content,
+		CreateTime: timestampPB(m.CreateTime),
+	}
+	if !m.ParentID.IsZero() {
+		c.ParentId = m.ParentID.Hex()
+	}
+	return c
+}
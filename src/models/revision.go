@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Revision keeps a version of an Article as it was before an update
+type Revision struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ArticleID primitive.ObjectID `bson:"article_id"`
+	Article   Article            `bson:"article"`
+	// CreateTime is when the Article was updated and the Revision was made
+	CreateTime time.Time `bson:"create_time"`
+}
+
+// NewRevision makes a Revision out of the Article before it gets updated
+func NewRevision(a Article) Revision {
+	return Revision{
+		ID:         primitive.NewObjectID(),
+		ArticleID:  a.ID,
+		Article:    a,
+		CreateTime: Now(),
+	}
+}
+
+// ToPB converts Revision to Protocol Buffer message
+func (m Revision) ToPB() *pb.Revision {
+	return &pb.Revision{
+		Id:         m.ID.Hex(),
+		ArticleId:  m.ArticleID.Hex(),
+		Article:    m.Article.ToPB(),
+		CreateTime: timestampPB(m.CreateTime),
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+)
+
+// Status is the lifecycle stage of an Article
+type Status string
+
+// Article statuses, articles created before statuses existed have none and count as published
+const (
+	StatusDraft     Status = "draft"
+	StatusPublished Status = "published"
+	StatusArchived  Status = "archived"
+)
+
+var statusToPB = map[Status]pb.Article_Status{
+	StatusDraft:     pb.Article_DRAFT,
+	StatusPublished: pb.Article_PUBLISHED,
+	StatusArchived:  pb.Article_ARCHIVED,
+}
+
+// StatusFromPB converts Protocol Buffers status, returns false for unspecified and unknown ones
+func StatusFromPB(s pb.Article_Status) (Status, bool) {
+	for k, v := range statusToPB {
+		if v == s {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// CurrentStatus returns the status of the Article, treating missing one as published
+func (m Article) CurrentStatus() Status {
+	if m.Status == "" {
+		return StatusPublished
+	}
+	return m.Status
+}
+
+// IsDue checks whether the Article is a draft scheduled to be published by "now"
+func (m Article) IsDue(now time.Time) bool {
+	return m.CurrentStatus() == StatusDraft && !m.PublishTime.IsZero() && !m.PublishTime.After(now)
+}
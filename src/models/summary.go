@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"example.com/grpc/blog/src/render"
+)
+
+// ExcerptLength is the maximum number of characters in Article excerpt
+var ExcerptLength = 200
+
+// WordsPerMinute is the reading speed reading time is estimated with
+const WordsPerMinute = 200
+
+// Summarize fills excerpt, word count and reading time out of the content
+// Repos call it whenever the content or its format is stored
+func (m *Article) Summarize() error {
+	text, err := render.Text(m.Content, m.ContentFormat)
+	if err != nil {
+		return err
+	}
+	m.Excerpt = excerpt(text, ExcerptLength)
+	m.WordCount = int64(len(strings.Fields(text)))
+	// started minute counts as a whole one
+	m.ReadingMinutes = (m.WordCount + WordsPerMinute - 1) / WordsPerMinute
+	return nil
+}
+
+// excerpt cuts the text to n characters at the end of the last sentence which fits
+// Text without a sentence end is cut at a word boundary and gets an ellipsis
+func excerpt(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	cut := runes[:n]
+	// a sentence ends with punctuation followed by a space or the cut
+	next := runes[n]
+	for i := len(cut) - 1; i > 0; i-- {
+		if !strings.ContainsRune(".!?", cut[i]) {
+			continue
+		}
+		if i == len(cut)-1 && next == ' ' || i < len(cut)-1 && cut[i+1] == ' ' {
+			return string(cut[:i+1])
+		}
+	}
+	s := string(cut)
+	if next != ' ' {
+		if i := strings.LastIndexByte(s, ' '); i > 0 {
+			s = s[:i]
+		}
+	}
+	return strings.TrimRight(s, " ,;:") + "…"
+}
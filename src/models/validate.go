@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits of Article fields, lengths are in characters
+const (
+	MaxTitleLength   = 200
+	MaxContentLength = 100000
+	MaxTags          = 20
+	MaxTagLength     = 50
+)
+
+// Violation describes why a field is invalid, the field is named after its bson key
+type Violation struct {
+	Field       string
+	Description string
+}
+
+// Rule declares constraints of a text field, every item is checked on its own for lists
+type Rule struct {
+	Field    string
+	Required bool
+	// MinLength applies to values which are set, MaxLength is unlimited when 0
+	MinLength int
+	MaxLength int
+	// MaxItems limits the length of list fields, unlimited when 0
+	MaxItems int
+	// Allowed checks every character, Chars describes the allowed ones
+	Allowed func(rune) bool
+	Chars   string
+}
+
+// ArticleRules are checked before Articles are stored
+var ArticleRules = []Rule{
+	{Field: FieldAuthorID, Required: true, MinLength: 24, MaxLength: 24, Allowed: isHexDigit, Chars: "hex digits"},
+	{Field: FieldTitle, Required: true, MaxLength: MaxTitleLength, Allowed: isPrintable, Chars: "printable characters"},
+	{Field: FieldContent, MaxLength: MaxContentLength, Allowed: isText, Chars: "printable characters and line breaks"},
+	{Field: FieldTags, MaxItems: MaxTags, MaxLength: MaxTagLength, Allowed: isTagChar, Chars: "letters, digits, spaces, '-' and '_'"},
+}
+
+// Validate checks the fields of the Article against ArticleRules, every violation is returned
+func (m Article) Validate(fields []string) []Violation {
+	var vs []Violation
+	for _, f := range fields {
+		for _, r := range ArticleRules {
+			if r.Field == f {
+				v, _ := m.Field(f)
+				vs = append(vs, r.check(v)...)
+			}
+		}
+	}
+	return vs
+}
+
+func (r Rule) check(v interface{}) []Violation {
+	switch v := v.(type) {
+	case string:
+		if d := r.checkText(v); d != "" {
+			return []Violation{{Field: r.Field, Description: d}}
+		}
+	case []string:
+		if r.Required && len(v) == 0 {
+			return []Violation{{Field: r.Field, Description: "Required"}}
+		}
+		if r.MaxItems > 0 && len(v) > r.MaxItems {
+			return []Violation{{Field: r.Field, Description: fmt.Sprintf("Must have at most %v items", r.MaxItems)}}
+		}
+		var vs []Violation
+		for i, s := range v {
+			if d := r.checkText(s); d != "" {
+				vs = append(vs, Violation{Field: fmt.Sprintf("%v[%v]", r.Field, i), Description: d})
+			}
+		}
+		return vs
+	}
+	return nil
+}
+
+// checkText returns the description of the first broken constraint, or an empty string
+func (r Rule) checkText(s string) string {
+	switch {
+	case s == "":
+		if r.Required {
+			return "Required"
+		}
+	case !utf8.ValidString(s):
+		return "Must be valid UTF-8"
+	case r.MinLength > 0 && utf8.RuneCountInString(s) < r.MinLength:
+		return fmt.Sprintf("Must be at least %v characters long", r.MinLength)
+	case r.MaxLength > 0 && utf8.RuneCountInString(s) > r.MaxLength:
+		return fmt.Sprintf("Must be at most %v characters long", r.MaxLength)
+	case r.Allowed != nil:
+		for _, c := range s {
+			if !r.Allowed(c) {
+				return fmt.Sprintf("Must contain only %v, got %q", r.Chars, c)
+			}
+		}
+	}
+	return ""
+}
+
+func isHexDigit(c rune) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// isPrintable rejects control characters and non-characters only
+// spaces and invisible ones like NBSP, soft hyphen and ZWJ are part of regular text
+func isPrintable(c rune) bool {
+	return !unicode.IsControl(c) && !isNonCharacter(c)
+}
+
+func isText(c rune) bool {
+	return isPrintable(c) || c == '\n' || c == '\r' || c == '\t'
+}
+
+// isNonCharacter checks for code points which are never assigned, like U+FFFE
+func isNonCharacter(c rune) bool {
+	return (0xFDD0 <= c && c <= 0xFDEF) || c&0xFFFE == 0xFFFE
+}
+
+func isTagChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == ' ' || c == '-' || c == '_'
+}
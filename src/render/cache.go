@@ -0,0 +1,67 @@
+package render
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache keeps recently rendered HTML, evicting the least recently used one
+// Nil Cache renders without caching
+type Cache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+type cacheItem struct {
+	key  string
+	html string
+}
+
+// NewCache returns a Cache of at most size items
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// HTML returns the rendered content by key, rendering it on a miss
+// The key must change whenever the content does
+func (c *Cache) HTML(key, content string, f Format) (string, error) {
+	if c == nil {
+		return HTML(content, f)
+	}
+	c.mu.Lock()
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*cacheItem).html, nil
+	}
+	c.mu.Unlock()
+	// rendering happens without the lock, the same content may rarely be rendered twice
+	h, err := HTML(content, f)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; !ok {
+		c.items[key] = c.order.PushFront(&cacheItem{key: key, html: h})
+		if c.order.Len() > c.size {
+			last := c.order.Back()
+			c.order.Remove(last)
+			delete(c.items, last.Value.(*cacheItem).key)
+		}
+	}
+	return h, nil
+}
+
+// Len returns the number of cached items
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,54 @@
+package render
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Format is the markup Article content is written in
+type Format string
+
+// Content formats, content without a format is plain text
+const (
+	FormatPlain    Format = "plain"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// markdown renders CommonMark with GFM tables, raw HTML is omitted
+var markdown = goldmark.New(goldmark.WithExtensions(extension.Table))
+
+// HTML renders the content into sanitized HTML
+func HTML(content string, f Format) (string, error) {
+	switch f {
+	case FormatMarkdown:
+		var b bytes.Buffer
+		if err := markdown.Convert([]byte(content), &b); err != nil {
+			return "", err
+		}
+		// goldmark already drops raw HTML and dangerous links, sanitizing is one more line of defense
+		return Sanitize(b.String()), nil
+	case FormatHTML:
+		return Sanitize(content), nil
+	}
+	return plainHTML(content), nil
+}
+
+// plainHTML escapes text turning blank lines into paragraphs and the rest of line breaks into <br>
+func plainHTML(s string) string {
+	var b strings.Builder
+	for _, p := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n\n") {
+		p = strings.Trim(p, "\n")
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(p), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package render
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowed lists elements which are kept with their allowed attributes, everything else is dropped
+var allowed = map[atom.Atom][]string{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: nil, atom.Span: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil, atom.Del: nil,
+	atom.Blockquote: nil, atom.Pre: nil, atom.Code: {"class"}, atom.Sub: nil, atom.Sup: nil,
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil, atom.Th: {"align"}, atom.Td: {"align"},
+	atom.A:   {"href", "title"},
+	atom.Img: {"src", "alt", "title"},
+}
+
+// dropped elements are removed together with their content
+var dropped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Noscript: true, atom.Template: true, atom.Textarea: true, atom.Select: true, atom.Title: true,
+}
+
+// codeClass is the only class allowed, it's how fenced code blocks name their language
+var codeClass = regexp.MustCompile(`^language-[\w+#-]+$`)
+
+// safeSchemes of URLs, relative URLs are allowed too
+var safeSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Sanitize keeps only allowed elements and attributes of the HTML, so it can't run scripts
+// Text of dropped elements is kept unless the element is dropped with its content
+func Sanitize(s string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	// depth of elements dropped with their content
+	skip := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return b.String()
+		}
+		t := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if dropped[t.DataAtom] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			attrs, ok := allowed[t.DataAtom]
+			if !ok {
+				continue
+			}
+			t.Attr = cleanAttrs(t.DataAtom, t.Attr, attrs)
+			b.WriteString(t.String())
+		case html.EndTagToken:
+			if dropped[t.DataAtom] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if _, ok := allowed[t.DataAtom]; ok && skip == 0 {
+				b.WriteString(t.String())
+			}
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(t.String())
+			}
+		}
+	}
+}
+
+// cleanAttrs keeps allowed attributes with safe values
+func cleanAttrs(a atom.Atom, attrs []html.Attribute, allowedAttrs []string) []html.Attribute {
+	res := make([]html.Attribute, 0, len(attrs))
+	for _, at := range attrs {
+		if at.Namespace != "" || !contains(allowedAttrs, at.Key) {
+			continue
+		}
+		switch at.Key {
+		case "href", "src":
+			if !safeURL(at.Val) {
+				continue
+			}
+		case "class":
+			if a != atom.Code || !codeClass.MatchString(at.Val) {
+				continue
+			}
+		}
+		res = append(res, at)
+	}
+	if a == atom.A {
+		res = append(res, html.Attribute{Key: "rel", Val: "nofollow noopener"})
+	}
+	return res
+}
+
+// safeURL checks that the URL is relative or uses a safe scheme
+func safeURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" && u.Opaque == "" || safeSchemes[strings.ToLower(u.Scheme)]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
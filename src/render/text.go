@@ -0,0 +1,41 @@
+package render
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// inline elements don't separate words of their text from the surrounding one
+var inline = map[atom.Atom]bool{
+	atom.A: true, atom.Span: true, atom.Strong: true, atom.B: true, atom.Em: true, atom.I: true,
+	atom.U: true, atom.S: true, atom.Del: true, atom.Code: true, atom.Sub: true, atom.Sup: true,
+}
+
+// Text returns the content without markup, whitespace is collapsed into single spaces
+func Text(content string, f Format) (string, error) {
+	if f != FormatMarkdown && f != FormatHTML {
+		return strings.Join(strings.Fields(content), " "), nil
+	}
+	h, err := HTML(content, f)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(h))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " "), nil
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			if !inline[atom.Lookup(name)] {
+				b.WriteByte(' ')
+			}
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package repo
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"time"
 
 	"example.com/grpc/blog/src/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,86 +15,499 @@ import (
 
 /*
 ArticleRepo provides basic Repository Interface for dealing with Articles
+Methods working with a single Article return *Error wrapping ErrNotFound, ErrInvalidID or ErrConflict
+when the Article is missing, its ID is malformed or its unique key is taken
 */
 type ArticleRepo interface {
 
-	// FillArticles populates the channel with Articles
+	// FillArticles populates the channel with Articles matching the query
 	// It also accepts "stop" channel which should be called explicitly
-	FillArticles(context.Context, chan<- models.Article, <-chan struct{}) error
+	FillArticles(context.Context, ArticleQuery, chan<- models.Article, <-chan struct{}) error
 
-	// AddArticle attempts to add an article
+	// AddArticle attempts to add an article, it sets ID and timestamps of the article
+	// Slug of the article gets a numeric suffix if it's already taken
 	// returns ID and error
 	AddArticle(context.Context, *models.Article) (string, error)
 
-	// UpdateArticle attempts to update an article and returns an error
-	UpdateArticle(context.Context, *models.Article) (*models.Article, error)
+	// AddArticles attempts to add Articles at once the way AddArticle does
+	// returns an error per Article, nil for added ones, and an error if the whole batch failed
+	AddArticles(context.Context, []*models.Article) ([]error, error)
 
-	// DeleteArticle attempts to delete an Article by ID and returns deleted Article and an error
-	DeleteArticle(context.Context, string) (*models.Article, error)
+	// UpdateArticle attempts to update listed fields of an article and bumps its update time and version
+	// Non zero Version of the article must match the stored one, otherwise *VersionConflictError is returned
+	// The previous version of the article is kept as a revision
+	// returns updated Article and an error
+	UpdateArticle(context.Context, *models.Article, []string) (*models.Article, error)
 
-	// GetArticle attempts to get an Article and returns a ref to an Article and an error
+	// DeleteArticle attempts to move an Article by ID to trash
+	// Non zero version must match the stored one, otherwise *VersionConflictError is returned
+	// returns deleted Article and an error
+	DeleteArticle(context.Context, string, int64) (*models.Article, error)
+
+	// DeleteArticles attempts to move Articles by IDs to trash at once
+	// returns deleted Articles by ID, missing ones and ones already in trash are left out
+	DeleteArticles(context.Context, []string) (map[string]models.Article, error)
+
+	// UndeleteArticle attempts to restore an Article by ID from trash
+	UndeleteArticle(context.Context, string) (*models.Article, error)
+
+	// PurgeDeleted removes Articles moved to trash before the time for good, together with revisions
+	// returns IDs of removed Articles, also along with an error when the Articles were removed but revisions were not
+	PurgeDeleted(context.Context, time.Time) ([]string, error)
+
+	// GetArticle attempts to get an Article which is not in trash
+	// returns a ref to an Article and an error
 	GetArticle(context.Context, string) (*models.Article, error)
+
+	// GetArticles gets Articles which are not in trash by IDs
+	// returns found Articles by ID, missing ones are left out
+	GetArticles(context.Context, []string) (map[string]models.Article, error)
+
+	// GetArticleBySlug attempts to get a published Article which is not in trash by its slug
+	GetArticleBySlug(context.Context, string) (*models.Article, error)
+
+	// GetArticlesByKey gets Articles, including ones in trash, by values of KeySlug or KeyExternalID
+	// returns found Articles by the key value
+	GetArticlesByKey(context.Context, string, []string) (map[string]models.Article, error)
+
+	// ListRevisions returns revisions of an Article by ID, newest first
+	ListRevisions(context.Context, string) ([]models.Revision, error)
+
+	// GetRevision attempts to get a revision by Article ID and revision ID
+	GetRevision(context.Context, string, string) (*models.Revision, error)
+
+	// SetStatus changes status and publish time of an Article by ID and bumps its update time and version
+	// Zero publish time removes it
+	SetStatus(context.Context, string, models.Status, time.Time) (*models.Article, error)
+
+	// Archive sets archived status of an Article by ID with a single update, drafts lose their publish time
+	// so they don't stay scheduled while published Articles keep it
+	Archive(context.Context, string) (*models.Article, error)
+
+	// PublishScheduled publishes live drafts which publish time is not after "now"
+	// returns the number of published Articles
+	PublishScheduled(context.Context, time.Time) (int64, error)
+
+	// ListTags returns every tag with the number of published live Articles having it
+	// ordered by the number of Articles, most used first
+	ListTags(context.Context) ([]TagCount, error)
+
+	// GetStats counts live Articles and their words in total and per author, tag and month
+	GetStats(context.Context) (*Stats, error)
+
+	// SearchArticles finds published live Articles containing any of the terms
+	// returns at most "limit" results ordered by relevance
+	SearchArticles(context.Context, []string, int64) ([]SearchResult, error)
+
+	// WatchArticles sends changes of Articles to the channel until the context is done, then closes it
+	// Changes after the event with the resume token are sent first, empty token starts with new changes
+	WatchArticles(context.Context, string, chan<- ArticleEvent) error
 }
 
 // MapArticleRepo is used for testing (or in-memory storage for Articles)
 type MapArticleRepo struct {
+	mu       sync.RWMutex
 	articles map[primitive.ObjectID]models.Article
+	// text is used for full-text search
+	text invertedIndex
+	tags invertedIndex
+	// slugs maps slugs onto Article IDs
+	slugs map[string]primitive.ObjectID
+	// externalIDs maps external IDs onto Article IDs
+	externalIDs map[string]primitive.ObjectID
+	// revisions of Articles, oldest first
+	revisions map[primitive.ObjectID][]models.Revision
+	// events notify watchers about changes
+	events eventHub
 }
 
 // NewMapRepo creates a struct literal of Map Repo and returns a pointer to it
 func NewMapRepo(m map[primitive.ObjectID]models.Article) *MapArticleRepo {
-	return &MapArticleRepo{
-		articles: m,
+	r := &MapArticleRepo{
+		articles:    m,
+		text:        make(invertedIndex),
+		tags:        make(invertedIndex),
+		slugs:       make(map[string]primitive.ObjectID),
+		externalIDs: make(map[string]primitive.ObjectID),
+		revisions:   make(map[primitive.ObjectID][]models.Revision),
+	}
+	for _, a := range m {
+		r.index(a)
 	}
+	return r
+}
+
+// index adds Article a to indexes, Articles in trash keep only their slugs and external IDs
+func (m *MapArticleRepo) index(a models.Article) {
+	if a.Slug != "" {
+		m.slugs[a.Slug] = a.ID
+	}
+	if a.ExternalID != "" {
+		m.externalIDs[a.ExternalID] = a.ID
+	}
+	if a.IsDeleted() {
+		return
+	}
+	m.text.add(a.ID, articleTerms(a))
+	m.tags.add(a.ID, a.Tags)
+}
+
+// unindex removes Article a from indexes
+func (m *MapArticleRepo) unindex(a models.Article) {
+	if a.Slug != "" {
+		delete(m.slugs, a.Slug)
+	}
+	if a.ExternalID != "" {
+		delete(m.externalIDs, a.ExternalID)
+	}
+	m.text.remove(a.ID, articleTerms(a))
+	m.tags.remove(a.ID, a.Tags)
+}
+
+// replace stores the new version of an Article keeping indexes up to date and notifies watchers
+func (m *MapArticleRepo) replace(a models.Article) {
+	old := m.articles[a.ID]
+	m.unindex(old)
+	m.articles[a.ID] = a
+	m.index(a)
+	if a.IsDeleted() && !old.IsDeleted() {
+		m.events.publish(EventDeleted, a)
+	} else {
+		m.events.publish(EventUpdated, a)
+	}
+}
+
+// live returns an Article by ID unless it's missing or in trash
+func (m *MapArticleRepo) live(id string) (models.Article, bool) {
+	a, err := m.liveArticle(id)
+	return a, err == nil
+}
+
+// liveArticle is live returning *Error when the Article can't be found
+func (m *MapArticleRepo) liveArticle(id string) (models.Article, error) {
+	oid, err := parseID(KindArticle, id)
+	if err != nil {
+		return models.Article{}, err
+	}
+	a, ok := m.articles[oid]
+	if !ok || a.IsDeleted() {
+		return models.Article{}, &Error{Kind: KindArticle, Key: id, Err: ErrNotFound}
+	}
+	return a, nil
 }
 
 // AddArticle to the map
 func (m *MapArticleRepo) AddArticle(ctx context.Context, a *models.Article) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.add(a)
+}
+
+// AddArticles to the map, the batch is added under a single lock
+func (m *MapArticleRepo) AddArticles(ctx context.Context, as []*models.Article) ([]error, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	errs := make([]error, len(as))
+	for i, a := range as {
+		_, errs[i] = m.add(a)
+	}
+	return errs, nil
+}
+
+// add stores a new Article, the lock must be held by the caller
+func (m *MapArticleRepo) add(a *models.Article) (string, error) {
+	if _, ok := m.externalIDs[a.ExternalID]; ok && a.ExternalID != "" {
+		return "", &Error{Kind: KindArticle, Key: a.ExternalID, Err: ErrConflict}
+	}
+	if err := a.Summarize(); err != nil {
+		return "", err
+	}
 	id := primitive.NewObjectID()
 	a.ID = id
+	a.CreateTime = models.Now()
+	a.UpdateTime = a.CreateTime
+	a.Version = 1
+	if a.Slug != "" {
+		a.Slug = m.uniqueSlug(a.Slug)
+	}
 	m.articles[id] = *a
-	return id.String(), nil
+	m.index(*a)
+	m.events.publish(EventCreated, *a)
+	return id.Hex(), nil
 }
 
 // GetArticle from the map
 func (m *MapArticleRepo) GetArticle(ctx context.Context, id string) (*models.Article, error) {
-	oid, _ := primitive.ObjectIDFromHex(id)
-	a, ok := m.articles[oid]
-	if !ok {
-		return nil, fmt.Errorf("Missing value for %v", id)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	a, err := m.liveArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetArticlesByKey from the map
+func (m *MapArticleRepo) GetArticlesByKey(ctx context.Context, key string, values []string) (map[string]models.Article, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var ids map[string]primitive.ObjectID
+	switch key {
+	case KeySlug:
+		ids = m.slugs
+	case KeyExternalID:
+		ids = m.externalIDs
+	default:
+		return nil, fmt.Errorf("Unknown key %v", key)
+	}
+	res := make(map[string]models.Article, len(values))
+	for _, v := range values {
+		if id, ok := ids[v]; ok {
+			res[v] = m.articles[id]
+		}
+	}
+	return res, nil
+}
+
+// GetArticles from the map
+func (m *MapArticleRepo) GetArticles(ctx context.Context, ids []string) (map[string]models.Article, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	res := make(map[string]models.Article, len(ids))
+	for _, id := range ids {
+		if a, ok := m.live(id); ok {
+			res[id] = a
+		}
+	}
+	return res, nil
+}
+
+// GetArticleBySlug from the map
+func (m *MapArticleRepo) GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	id, ok := m.slugs[slug]
+	if !ok || m.articles[id].IsDeleted() || m.articles[id].CurrentStatus() != models.StatusPublished {
+		return nil, &Error{Kind: KindArticle, Key: slug, Err: ErrNotFound}
 	}
+	a := m.articles[id]
 	return &a, nil
 }
 
-// DeleteArticle from the map
-func (m *MapArticleRepo) DeleteArticle(ctx context.Context, id string) (*models.Article, error) {
-	oid, _ := primitive.ObjectIDFromHex(id)
+// uniqueSlug returns the first variant of the slug which is not taken yet
+func (m *MapArticleRepo) uniqueSlug(base string) string {
+	return freeSlug(base, func(s string) bool {
+		_, ok := m.slugs[s]
+		return ok
+	})
+}
+
+// DeleteArticle moves an Article to trash inside the map
+func (m *MapArticleRepo) DeleteArticle(ctx context.Context, id string, version int64) (*models.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.liveArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != a.Version {
+		return nil, &VersionConflictError{ID: id, Expected: version, Current: a.Version}
+	}
+	a = m.trash(a, models.Now())
+	return &a, nil
+}
+
+// DeleteArticles moves Articles to trash inside the map, the batch is deleted under a single lock
+func (m *MapArticleRepo) DeleteArticles(ctx context.Context, ids []string) (map[string]models.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := models.Now()
+	res := make(map[string]models.Article, len(ids))
+	for _, id := range ids {
+		if a, ok := m.live(id); ok {
+			res[id] = m.trash(a, now)
+		}
+	}
+	return res, nil
+}
+
+// trash moves an Article to trash, the lock must be held by the caller
+func (m *MapArticleRepo) trash(a models.Article, now time.Time) models.Article {
+	a.DeleteTime = now
+	a.UpdateTime = now
+	a.Version++
+	m.replace(a)
+	return a
+}
+
+// UndeleteArticle restores an Article from trash inside the map
+func (m *MapArticleRepo) UndeleteArticle(ctx context.Context, id string) (*models.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oid, err := parseID(KindArticle, id)
+	if err != nil {
+		return nil, err
+	}
 	a, ok := m.articles[oid]
-	if !ok {
-		return nil, fmt.Errorf("Missing value for %v", id)
+	if !ok || !a.IsDeleted() {
+		return nil, &Error{Kind: KindArticle, Key: id, Err: ErrNotFound}
 	}
-	delete(m.articles, oid)
+	a.DeleteTime = time.Time{}
+	a.UpdateTime = models.Now()
+	a.Version++
+	m.replace(a)
 	return &a, nil
 }
 
+// PurgeDeleted Articles from the map
+func (m *MapArticleRepo) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := []string{}
+	for id, a := range m.articles {
+		if !a.IsDeleted() || a.DeleteTime.After(before) {
+			continue
+		}
+		m.unindex(a)
+		delete(m.articles, id)
+		delete(m.revisions, id)
+		m.events.publish(EventDeleted, models.Article{ID: id})
+		ids = append(ids, id.Hex())
+	}
+	return ids, nil
+}
+
 // UpdateArticle inside the map
-func (m *MapArticleRepo) UpdateArticle(ctx context.Context, a *models.Article) (*models.Article, error) {
-	ua, ok := m.articles[a.ID]
-	if !ok {
-		return nil, fmt.Errorf("Missing old model %v", a)
+func (m *MapArticleRepo) UpdateArticle(ctx context.Context, a *models.Article, fields []string) (*models.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ua, err := m.liveArticle(a.ID.Hex())
+	if err != nil {
+		return nil, err
 	}
-	m.articles[a.ID] = ua
-	return a, nil
+	if a.Version != 0 && a.Version != ua.Version {
+		return nil, &VersionConflictError{ID: a.ID.Hex(), Expected: a.Version, Current: ua.Version}
+	}
+	for _, f := range fields {
+		if !ua.CopyField(*a, f) {
+			return nil, fmt.Errorf("Unknown field %v", f)
+		}
+	}
+	if touchesContent(fields) {
+		if err := ua.Summarize(); err != nil {
+			return nil, err
+		}
+	}
+	ua.UpdateTime = models.Now()
+	ua.Version++
+	m.revisions[a.ID] = append(m.revisions[a.ID], models.NewRevision(m.articles[a.ID]))
+	m.replace(ua)
+	return &ua, nil
+}
+
+// ListRevisions of an Article inside the map
+func (m *MapArticleRepo) ListRevisions(ctx context.Context, articleID string) ([]models.Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	oid, err := parseID(KindArticle, articleID)
+	if err != nil {
+		return nil, err
+	}
+	revs := m.revisions[oid]
+	res := make([]models.Revision, 0, len(revs))
+	for i := len(revs) - 1; i >= 0; i-- {
+		res = append(res, revs[i])
+	}
+	return res, nil
+}
+
+// GetRevision of an Article from the map
+func (m *MapArticleRepo) GetRevision(ctx context.Context, articleID string, revisionID string) (*models.Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	aid, err := parseID(KindArticle, articleID)
+	if err != nil {
+		return nil, err
+	}
+	rid, err := parseID(KindRevision, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range m.revisions[aid] {
+		if r.ID == rid {
+			return &r, nil
+		}
+	}
+	return nil, &Error{Kind: KindRevision, Key: revisionID, Err: ErrNotFound}
+}
+
+// SetStatus of an Article inside the map
+func (m *MapArticleRepo) SetStatus(ctx context.Context, id string, s models.Status, publishTime time.Time) (*models.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.liveArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	a.Status = s
+	a.PublishTime = publishTime
+	a.UpdateTime = models.Now()
+	a.Version++
+	m.replace(a)
+	return &a, nil
 }
 
-// FillArticles from the map
-func (m *MapArticleRepo) FillArticles(ctx context.Context, out chan<- models.Article, stop <-chan struct{}) error {
+// Archive an Article inside the map
+func (m *MapArticleRepo) Archive(ctx context.Context, id string) (*models.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, err := m.liveArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	if a.CurrentStatus() == models.StatusDraft {
+		a.PublishTime = time.Time{}
+	}
+	a.Status = models.StatusArchived
+	a.UpdateTime = models.Now()
+	a.Version++
+	m.replace(a)
+	return &a, nil
+}
+
+// PublishScheduled drafts inside the map
+func (m *MapArticleRepo) PublishScheduled(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := int64(0)
+	for _, a := range m.articles {
+		if !a.IsDue(now) || a.IsDeleted() {
+			continue
+		}
+		a.Status = models.StatusPublished
+		a.UpdateTime = models.Now()
+		a.Version++
+		m.replace(a)
+		n++
+	}
+	return n, nil
+}
+
+// FillArticles from the map in the same order MongoDB would return them
+func (m *MapArticleRepo) FillArticles(ctx context.Context, q ArticleQuery, out chan<- models.Article, stop <-chan struct{}) error {
 	defer close(out)
-	// get map keys to traverse 1 by 1 later
+	m.mu.RLock()
+	list := m.query(q)
+	m.mu.RUnlock()
+	if q.Summary {
+		for i := range list {
+			list[i].Content = ""
+		}
+	}
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
-		for _, v := range m.articles {
+		for _, v := range list {
 			select {
 			case <-stop:
 				// stop signal
@@ -117,3 +533,76 @@ func (m *MapArticleRepo) FillArticles(ctx context.Context, out chan<- models.Art
 	wg.Wait()
 	return nil
 }
+
+// candidates narrows down Articles to check against the filter using indexes
+func (m *MapArticleRepo) candidates(f ArticleFilter) map[primitive.ObjectID]models.Article {
+	if len(f.Tags) == 0 {
+		return m.articles
+	}
+	res := make(map[primitive.ObjectID]models.Article)
+	for id := range m.tags.lookupAll(f.Tags) {
+		res[id] = m.articles[id]
+	}
+	return res
+}
+
+// query returns Articles matching the query in requested order
+func (m *MapArticleRepo) query(q ArticleQuery) []models.Article {
+	list := make([]models.Article, 0, len(m.articles))
+	for _, v := range m.candidates(q.Filter) {
+		if q.Filter.matches(v) && q.isAfterCursor(v) {
+			list = append(list, v)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return q.less(list[i], list[j])
+	})
+	if q.Limit > 0 && int64(len(list)) > q.Limit {
+		list = list[:q.Limit]
+	}
+	return list
+}
+
+// SearchArticles using the inverted index of the map
+func (m *MapArticleRepo) SearchArticles(ctx context.Context, terms []string, limit int64) ([]SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	res := []SearchResult{}
+	for id := range m.text.lookup(terms) {
+		a := m.articles[id]
+		if a.CurrentStatus() != models.StatusPublished {
+			continue
+		}
+		res = append(res, SearchResult{Article: a, Score: textScore(a, terms)})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return bytes.Compare(res[i].Article.ID[:], res[j].Article.ID[:]) < 0
+	})
+	if limit > 0 && int64(len(res)) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+// ListTags from the tag index
+func (m *MapArticleRepo) ListTags(ctx context.Context) ([]TagCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	res := make([]TagCount, 0, len(m.tags))
+	for t, ids := range m.tags {
+		n := int64(0)
+		for id := range ids {
+			if m.articles[id].CurrentStatus() == models.StatusPublished {
+				n++
+			}
+		}
+		if n > 0 {
+			res = append(res, TagCount{Tag: t, Count: n})
+		}
+	}
+	sortTagCounts(res)
+	return res, nil
+}
@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"example.com/grpc/blog/src/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+AuthorRepo provides basic Repository Interface for dealing with Authors
+*/
+type AuthorRepo interface {
+
+	// AddAuthor attempts to add an Author, it sets ID and timestamps of the Author
+	// returns ID and error
+	AddAuthor(context.Context, *models.Author) (string, error)
+
+	// GetAuthor attempts to get an Author by ID
+	GetAuthor(context.Context, string) (*models.Author, error)
+
+	// UpdateAuthor attempts to update listed fields of an Author and bumps its update time
+	// returns updated Author and an error
+	UpdateAuthor(context.Context, *models.Author, []string) (*models.Author, error)
+
+	// DeleteAuthor attempts to delete an Author by ID
+	// returns deleted Author and an error
+	DeleteAuthor(context.Context, string) (*models.Author, error)
+
+	// ListAuthors returns Authors ordered by ID which go after the given one
+	// Zero limit returns all of them
+	ListAuthors(context.Context, primitive.ObjectID, int64) ([]models.Author, error)
+}
+
+// MapAuthorRepo is used for testing (or in-memory storage for Authors)
+type MapAuthorRepo struct {
+	mu      sync.RWMutex
+	authors map[primitive.ObjectID]models.Author
+}
+
+// NewMapAuthorRepo creates a struct literal of Map Author Repo and returns a pointer to it
+func NewMapAuthorRepo(m map[primitive.ObjectID]models.Author) *MapAuthorRepo {
+	return &MapAuthorRepo{
+		authors: m,
+	}
+}
+
+// AddAuthor to the map
+func (m *MapAuthorRepo) AddAuthor(ctx context.Context, a *models.Author) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := primitive.NewObjectID()
+	a.ID = id
+	a.CreateTime = models.Now()
+	a.UpdateTime = a.CreateTime
+	m.authors[id] = *a
+	return id.Hex(), nil
+}
+
+// GetAuthor from the map
+func (m *MapAuthorRepo) GetAuthor(ctx context.Context, id string) (*models.Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	oid, err := parseID(KindAuthor, id)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := m.authors[oid]
+	if !ok {
+		return nil, &Error{Kind: KindAuthor, Key: id, Err: ErrNotFound}
+	}
+	return &a, nil
+}
+
+// UpdateAuthor inside the map
+func (m *MapAuthorRepo) UpdateAuthor(ctx context.Context, a *models.Author, fields []string) (*models.Author, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ua, ok := m.authors[a.ID]
+	if !ok {
+		return nil, &Error{Kind: KindAuthor, Key: a.ID.Hex(), Err: ErrNotFound}
+	}
+	for _, f := range fields {
+		if !ua.CopyField(*a, f) {
+			return nil, fmt.Errorf("Unknown field %v", f)
+		}
+	}
+	ua.UpdateTime = models.Now()
+	m.authors[a.ID] = ua
+	return &ua, nil
+}
+
+// DeleteAuthor from the map
+func (m *MapAuthorRepo) DeleteAuthor(ctx context.Context, id string) (*models.Author, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oid, err := parseID(KindAuthor, id)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := m.authors[oid]
+	if !ok {
+		return nil, &Error{Kind: KindAuthor, Key: id, Err: ErrNotFound}
+	}
+	delete(m.authors, oid)
+	return &a, nil
+}
+
+// ListAuthors from the map
+func (m *MapAuthorRepo) ListAuthors(ctx context.Context, after primitive.ObjectID, limit int64) ([]models.Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := []models.Author{}
+	for id, a := range m.authors {
+		if after.IsZero() || bytes.Compare(id[:], after[:]) > 0 {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].ID[:], list[j].ID[:]) < 0
+	})
+	if limit > 0 && int64(len(list)) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
@@ -0,0 +1,172 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"example.com/grpc/blog/src/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+CommentRepo provides basic Repository Interface for dealing with Comments
+*/
+type CommentRepo interface {
+
+	// FillComments populates the channel with Comments matching the query, oldest first
+	// It stops reading once the "stop" channel is closed or ctx is done
+	FillComments(context.Context, CommentQuery, chan<- models.Comment, <-chan struct{}) error
+
+	// AddComment attempts to add a Comment, it sets ID and create time of the Comment
+	// returns ID and error
+	AddComment(context.Context, *models.Comment) (string, error)
+
+	// GetComment attempts to get a Comment by ID
+	GetComment(context.Context, string) (*models.Comment, error)
+
+	// DeleteComment attempts to remove a Comment by ID together with all replies to it
+	// returns the number of removed Comments
+	DeleteComment(context.Context, string) (int64, error)
+
+	// DeleteArticleComments removes every Comment of the Articles by IDs
+	// returns the number of removed Comments
+	DeleteArticleComments(context.Context, []string) (int64, error)
+}
+
+// CommentQuery describes which Comments of an Article to fetch
+type CommentQuery struct {
+	ArticleID primitive.ObjectID
+	// ParentID limits Comments to replies of the Comment, nested ones included
+	ParentID primitive.ObjectID
+	// After is the ID of the last Comment of the previous page
+	After primitive.ObjectID
+	// Limit is the maximum number of Comments, zero means no limit
+	Limit int64
+}
+
+// matches checks whether the Comment satisfies the query, ignoring limit
+func (q CommentQuery) matches(c models.Comment) bool {
+	if c.ArticleID != q.ArticleID {
+		return false
+	}
+	if !q.ParentID.IsZero() && !c.HasAncestor(q.ParentID) {
+		return false
+	}
+	return q.After.IsZero() || bytes.Compare(c.ID[:], q.After[:]) > 0
+}
+
+// MapCommentRepo is used for testing (or in-memory storage for Comments)
+type MapCommentRepo struct {
+	mu       sync.RWMutex
+	comments map[primitive.ObjectID]models.Comment
+}
+
+// NewMapCommentRepo creates a struct literal of Map Comment Repo and returns a pointer to it
+func NewMapCommentRepo(m map[primitive.ObjectID]models.Comment) *MapCommentRepo {
+	return &MapCommentRepo{
+		comments: m,
+	}
+}
+
+// AddComment to the map
+func (m *MapCommentRepo) AddComment(ctx context.Context, c *models.Comment) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := primitive.NewObjectID()
+	c.ID = id
+	c.CreateTime = models.Now()
+	m.comments[id] = *c
+	return id.Hex(), nil
+}
+
+// GetComment from the map
+func (m *MapCommentRepo) GetComment(ctx context.Context, id string) (*models.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	oid, err := parseID(KindComment, id)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := m.comments[oid]
+	if !ok {
+		return nil, &Error{Kind: KindComment, Key: id, Err: ErrNotFound}
+	}
+	return &c, nil
+}
+
+// DeleteComment with replies from the map
+func (m *MapCommentRepo) DeleteComment(ctx context.Context, id string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oid, err := parseID(KindComment, id)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := m.comments[oid]; !ok {
+		return 0, &Error{Kind: KindComment, Key: id, Err: ErrNotFound}
+	}
+	n := int64(0)
+	for k, c := range m.comments {
+		if k == oid || c.HasAncestor(oid) {
+			delete(m.comments, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// DeleteArticleComments from the map
+func (m *MapCommentRepo) DeleteArticleComments(ctx context.Context, articleIDs []string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make(map[primitive.ObjectID]bool, len(articleIDs))
+	for _, id := range articleIDs {
+		oid, _ := primitive.ObjectIDFromHex(id)
+		ids[oid] = true
+	}
+	n := int64(0)
+	for k, c := range m.comments {
+		if ids[c.ArticleID] {
+			delete(m.comments, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// FillComments fills the channel with Comments from the map
+func (m *MapCommentRepo) FillComments(ctx context.Context, q CommentQuery, out chan<- models.Comment, stop <-chan struct{}) error {
+	defer close(out)
+	m.mu.RLock()
+	list := m.query(q)
+	m.mu.RUnlock()
+	for _, v := range list {
+		select {
+		case out <- v:
+		case <-stop:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// query returns Comments matching the query ordered by ID, which follows creation order
+func (m *MapCommentRepo) query(q CommentQuery) []models.Comment {
+	list := []models.Comment{}
+	for _, c := range m.comments {
+		if q.matches(c) {
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].ID[:], list[j].ID[:]) < 0
+	})
+	if q.Limit > 0 && int64(len(list)) > q.Limit {
+		list = list[:q.Limit]
+	}
+	return list
+}
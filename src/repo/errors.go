@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Errors repos wrap into *Error, callers check them with errors.Is
+var (
+	// ErrNotFound means the document doesn't exist, live Articles are not found in trash and the other way round
+	ErrNotFound = errors.New("Not found")
+	// ErrInvalidID means the ID is not a hex ObjectID
+	ErrInvalidID = errors.New("Invalid ID")
+	// ErrConflict means a unique key of the document is taken by another one
+	ErrConflict = errors.New("Conflict")
+)
+
+// Kinds of documents in Error, named after their Protocol Buffers messages
+const (
+	KindArticle  = "blog.Article"
+	KindRevision = "blog.Revision"
+	KindComment  = "blog.Comment"
+	KindAuthor   = "blog.Author"
+)
+
+// Error tells which document a repo call failed on
+type Error struct {
+	Kind string
+	// Key identifies the document, it's the ID unless the document was looked up by another key
+	Key string
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v %v: %v", e.Kind, e.Key, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// parseID parses the hex ID of a document
+func parseID(kind, id string) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return oid, &Error{Kind: kind, Key: id, Err: ErrInvalidID}
+	}
+	return oid, nil
+}
+
+// mongoError converts MongoDB errors about a document into *Error, other errors are returned as they are
+func mongoError(kind, key string, err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return &Error{Kind: kind, Key: key, Err: ErrNotFound}
+	case isDuplicateKey(err):
+		return &Error{Kind: kind, Key: key, Err: ErrConflict}
+	}
+	return err
+}
+
+// VersionConflictError is returned when an Article was changed since the expected version
+type VersionConflictError struct {
+	ID       string
+	Expected int64
+	Current  int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("Article %v has version %v, expected %v", e.ID, e.Current, e.Expected)
+}
@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"math"
+
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/search"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Weights of Article fields in full-text search, same as in MongoDB text index
+const (
+	titleWeight   = 10
+	contentWeight = 1
+)
+
+// invertedIndex maps terms onto IDs of Articles containing them
+type invertedIndex map[string]map[primitive.ObjectID]struct{}
+
+// add indexes terms of Article with the ID
+func (x invertedIndex) add(id primitive.ObjectID, terms []string) {
+	for _, t := range terms {
+		ids, ok := x[t]
+		if !ok {
+			ids = make(map[primitive.ObjectID]struct{})
+			x[t] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// remove drops terms of Article with the ID from the index
+func (x invertedIndex) remove(id primitive.ObjectID, terms []string) {
+	for _, t := range terms {
+		delete(x[t], id)
+		if len(x[t]) == 0 {
+			delete(x, t)
+		}
+	}
+}
+
+// lookup returns IDs of Articles containing any of the terms
+func (x invertedIndex) lookup(terms []string) map[primitive.ObjectID]struct{} {
+	ids := make(map[primitive.ObjectID]struct{})
+	for _, t := range terms {
+		for id := range x[t] {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// lookupAll returns IDs of Articles containing all of the terms
+func (x invertedIndex) lookupAll(terms []string) map[primitive.ObjectID]struct{} {
+	ids := make(map[primitive.ObjectID]struct{})
+	if len(terms) == 0 {
+		return ids
+	}
+	for id := range x[terms[0]] {
+		ids[id] = struct{}{}
+	}
+	for _, t := range terms[1:] {
+		for id := range ids {
+			if _, ok := x[t][id]; !ok {
+				delete(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// articleTerms returns unique terms of Article's title and content
+func articleTerms(a models.Article) []string {
+	return search.Terms(a.Title + " " + a.Content)
+}
+
+// textScore mimics MongoDB text score of Article a for the terms
+func textScore(a models.Article, terms []string) float64 {
+	return titleWeight*fieldScore(a.Title, terms) + contentWeight*fieldScore(a.Content, terms)
+}
+
+// fieldScore scores a single field the way MongoDB does:
+// every repetition of a term adds less, and shorter fields score higher
+func fieldScore(s string, terms []string) float64 {
+	tokens := search.Tokens(s)
+	if len(tokens) == 0 {
+		return 0
+	}
+	counts := make(map[string]int)
+	for _, t := range tokens {
+		counts[t.Term]++
+	}
+	score := 0.0
+	for _, t := range terms {
+		c := counts[t]
+		if c == 0 {
+			continue
+		}
+		freq := 0.0
+		for i := 0; i < c; i++ {
+			freq += 1 / math.Pow(2, float64(i))
+		}
+		coeff := 0.5*float64(c)/float64(len(tokens)) + 0.5
+		score += freq * coeff
+	}
+	return score
+}
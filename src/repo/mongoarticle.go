@@ -2,9 +2,14 @@ package repo
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"example.com/grpc/blog/src/models"
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,19 +21,101 @@ import (
 // MongoArticleRepo is the Article repository implementation in MongoDB
 type MongoArticleRepo struct {
 	c *mongo.Collection
+	// revisions keeps previous versions of articles
+	revisions *mongo.Collection
 }
 
 // NewMongoArticleRepo returns initialized MongoDB article repo
 func NewMongoArticleRepo(c *mongo.Client) *MongoArticleRepo {
+	db := c.Database(os.Getenv("DB"))
 	return &MongoArticleRepo{
-		c: c.Database(os.Getenv("DB")).Collection("articles"),
+		c:         db.Collection("articles"),
+		revisions: db.Collection("revisions"),
 	}
 }
 
+// Unique indexes of articles, duplicate key errors are told apart by them
+const (
+	slugIndex       = "article_slug"
+	externalIDIndex = "article_external_id"
+)
+
+// EnsureIndexes creates indexes the repo relies on
+func (r *MongoArticleRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.c.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// language "none" disables stemming and stop words, so terms match the in-memory index
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "content", Value: "text"}},
+			Options: options.Index().
+				SetName("article_text").
+				SetDefaultLanguage("none").
+				SetWeights(bson.M{"title": titleWeight, "content": contentWeight}),
+		},
+		{
+			Keys:    bson.D{{Key: "tags", Value: 1}},
+			Options: options.Index().SetName("article_tags"),
+		},
+		{
+			// sparse since articles created before slugs existed don't have one
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetName(slugIndex).SetUnique(true).SetSparse(true),
+		},
+		{
+			// used by the scheduler to find due drafts
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "publish_time", Value: 1}},
+			Options: options.Index().SetName("article_status_publish_time"),
+		},
+		{
+			// sparse since only imported articles have one
+			Keys:    bson.D{{Key: "external_id", Value: 1}},
+			Options: options.Index().SetName(externalIDIndex).SetUnique(true).SetSparse(true),
+		},
+		{
+			// used by the purger to find articles which stayed in trash long enough
+			Keys:    bson.D{{Key: "delete_time", Value: 1}},
+			Options: options.Index().SetName("article_delete_time").SetSparse(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.revisions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "article_id", Value: 1}, {Key: "_id", Value: -1}},
+		Options: options.Index().SetName("revision_article"),
+	})
+	return err
+}
+
+// slugInsertAttempts limits retries when another article takes the same slug concurrently
+const slugInsertAttempts = 3
+
 // AddArticle implements ArticleRepo.AddArticle by persisting articles in MongoDB
 func (r *MongoArticleRepo) AddArticle(ctx context.Context, a *models.Article) (id string, err error) {
-	res, err := r.c.InsertOne(ctx, a)
+	if err := a.Summarize(); err != nil {
+		return "", err
+	}
+	a.ID = primitive.NewObjectID()
+	a.CreateTime = models.Now()
+	a.UpdateTime = a.CreateTime
+	a.Version = 1
+	base := a.Slug
+	var res *mongo.InsertOneResult
+	for i := 0; i < slugInsertAttempts; i++ {
+		if base != "" {
+			if a.Slug, err = r.uniqueSlug(ctx, base, nil); err != nil {
+				return "", err
+			}
+		}
+		res, err = r.c.InsertOne(ctx, a)
+		// only the slug is worth retrying, another one is picked
+		if duplicateIndex(err) != slugIndex {
+			break
+		}
+	}
 	if err != nil {
+		if duplicateIndex(err) == externalIDIndex && a.ExternalID != "" {
+			return "", &Error{Kind: KindArticle, Key: a.ExternalID, Err: ErrConflict}
+		}
 		return "", err
 	}
 	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
@@ -37,10 +124,126 @@ func (r *MongoArticleRepo) AddArticle(ctx context.Context, a *models.Article) (i
 	return "", fmt.Errorf("Got wrong type for Mongo Object ID")
 }
 
+// AddArticles adds articles with a single InsertMany
+// Articles which slugs got taken in the meantime are retried one by one with AddArticle
+func (r *MongoArticleRepo) AddArticles(ctx context.Context, as []*models.Article) ([]error, error) {
+	now := models.Now()
+	// slugs taken by the batch itself
+	batch := make(map[string]bool, len(as))
+	bases := make([]string, len(as))
+	docs := make([]interface{}, 0, len(as))
+	for i, a := range as {
+		if err := a.Summarize(); err != nil {
+			return nil, err
+		}
+		a.ID = primitive.NewObjectID()
+		a.CreateTime = now
+		a.UpdateTime = now
+		a.Version = 1
+		bases[i] = a.Slug
+		if a.Slug != "" {
+			s, err := r.uniqueSlug(ctx, a.Slug, batch)
+			if err != nil {
+				return nil, err
+			}
+			a.Slug = s
+			batch[s] = true
+		}
+		docs = append(docs, a)
+	}
+	errs := make([]error, len(as))
+	if len(docs) == 0 {
+		return errs, nil
+	}
+	_, err := r.c.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	var bwe mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bwe) {
+		return nil, err
+	}
+	for _, we := range bwe.WriteErrors {
+		if we.Code != 11000 {
+			errs[we.Index] = we
+			continue
+		}
+		as[we.Index].Slug = bases[we.Index]
+		_, errs[we.Index] = r.AddArticle(ctx, as[we.Index])
+	}
+	return errs, nil
+}
+
+// uniqueSlug returns the first variant of the slug which is not taken yet either in the collection or in batch
+func (r *MongoArticleRepo) uniqueSlug(ctx context.Context, base string, batch map[string]bool) (string, error) {
+	c, err := r.c.Find(ctx,
+		bson.M{"slug": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(base) + "(-[0-9]+)?$"}},
+		options.Find().SetProjection(bson.M{"slug": 1}))
+	if err != nil {
+		return "", err
+	}
+	taken := []models.Article{}
+	if err := c.All(ctx, &taken); err != nil {
+		return "", err
+	}
+	set := make(map[string]bool, len(taken))
+	for _, t := range taken {
+		set[t.Slug] = true
+	}
+	return freeSlug(base, func(s string) bool { return set[s] || batch[s] }), nil
+}
+
+// isDuplicateKey checks whether the error is a violation of unique index
+func isDuplicateKey(err error) bool {
+	var we mongo.WriteException
+	if !errors.As(err, &we) {
+		return false
+	}
+	for _, e := range we.WriteErrors {
+		if e.Code == 11000 {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicateKeyIndex finds the index name in duplicate key error messages like
+// "E11000 duplicate key error collection: blog.articles index: article_slug dup key: { slug: "a" }"
+var duplicateKeyIndex = regexp.MustCompile(`index: (\S+) dup key`)
+
+// duplicateIndex returns the name of the unique index the error violates, or an empty string
+func duplicateIndex(err error) string {
+	var we mongo.WriteException
+	if !errors.As(err, &we) {
+		return ""
+	}
+	for _, e := range we.WriteErrors {
+		if e.Code != 11000 {
+			continue
+		}
+		if m := duplicateKeyIndex.FindStringSubmatch(e.Message); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
 // FillArticles graps documents from MongoDB and sends to "out" channel
-func (r *MongoArticleRepo) FillArticles(ctx context.Context, out chan<- models.Article, stop <-chan struct{}) error {
+func (r *MongoArticleRepo) FillArticles(ctx context.Context, q ArticleQuery, out chan<- models.Article, stop <-chan struct{}) error {
 	defer close(out)
-	c, err := r.c.Find(ctx, bson.D{})
+	filter := mongoFilter(q.Filter)
+	if q.After != nil {
+		after, err := mongoCursor(q)
+		if err != nil {
+			return err
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+	opts := options.Find().SetSort(mongoSort(q))
+	if q.Limit > 0 {
+		opts.SetLimit(q.Limit)
+	}
+	if q.Summary {
+		opts.SetProjection(bson.M{"content": 0})
+	}
+	c, err := r.c.Find(ctx, filter, opts)
 	if err != nil {
 		return err
 	}
@@ -84,43 +287,616 @@ func (r *MongoArticleRepo) FillArticles(ctx context.Context, out chan<- models.A
 	return <-e
 }
 
-// UpdateArticle attempts to update an article
-func (r *MongoArticleRepo) UpdateArticle(ctx context.Context, a *models.Article) (*models.Article, error) {
-	res := r.c.FindOneAndUpdate(ctx, bson.M{"_id": a.ID}, bson.M{"$set": a}, options.FindOneAndUpdate().SetReturnDocument(options.After))
-	m := models.Article{}
-	err := res.Decode(&m)
+// notDeleted matches articles which are not in trash
+var notDeleted = bson.M{"$exists": false}
+
+// liveByID matches an article by ID unless it's in trash
+func liveByID(id primitive.ObjectID) bson.M {
+	return bson.M{"_id": id, "delete_time": notDeleted}
+}
+
+// mongoFilter builds MongoDB query out of ArticleFilter
+func mongoFilter(f ArticleFilter) bson.M {
+	filter := bson.M{"delete_time": notDeleted}
+	if f.Deleted {
+		filter["delete_time"] = bson.M{"$exists": true}
+	}
+	if f.AuthorID != "" {
+		filter["author_id"] = f.AuthorID
+	}
+	title := bson.A{}
+	if f.TitlePrefix != "" {
+		title = append(title, bson.M{"title": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(f.TitlePrefix)}})
+	}
+	if f.TitleContains != "" {
+		title = append(title, bson.M{"title": primitive.Regex{Pattern: regexp.QuoteMeta(f.TitleContains), Options: "i"}})
+	}
+	if len(title) > 0 {
+		filter["$and"] = title
+	}
+	if f.ContentContains != "" {
+		filter["content"] = primitive.Regex{Pattern: regexp.QuoteMeta(f.ContentContains), Options: "i"}
+	}
+	if len(f.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": f.Tags}
+	}
+	if len(f.Statuses) > 0 {
+		filter["status"] = statusFilter(f.Statuses...)
+	}
+	return filter
+}
+
+// mongoSort builds sort document, ID is always used as a tie breaker
+func mongoSort(q ArticleQuery) bson.D {
+	dir := 1
+	if q.Desc {
+		dir = -1
+	}
+	sort := bson.D{}
+	if k := q.Sort.bsonKey(); k != "" {
+		sort = append(sort, bson.E{Key: k, Value: dir})
+	}
+	return append(sort, bson.E{Key: "_id", Value: dir})
+}
+
+// mongoCursor builds range query for documents after the query cursor
+// Documents without the field sort first like zero values do in memory, so they're matched along with them
+func mongoCursor(q ArticleQuery) (bson.M, error) {
+	op := "$gt"
+	if q.Desc {
+		op = "$lt"
+	}
+	k := q.Sort.bsonKey()
+	if k == "" {
+		return bson.M{"_id": bson.M{op: q.After.ID}}, nil
+	}
+	v, err := q.Sort.bsonValue(q.After.Key)
 	if err != nil {
 		return nil, err
 	}
-	return &m, nil
+	if q.After.Key == q.Sort.key(models.Article{}) {
+		// the cursor is among missing and zero values, which tie
+		return bson.M{"$or": bson.A{
+			bson.M{k: bson.M{op: v}},
+			bson.M{k: bson.M{"$in": bson.A{nil, v}}, "_id": bson.M{op: q.After.ID}},
+		}}, nil
+	}
+	after := bson.A{
+		bson.M{k: bson.M{op: v}},
+		bson.M{k: v, "_id": bson.M{op: q.After.ID}},
+	}
+	if q.Desc {
+		// missing values come last, comparisons never match them
+		after = append(after, bson.M{k: nil})
+	}
+	return bson.M{"$or": after}, nil
 }
 
-// DeleteArticle attempts to delete article by object id
-func (r *MongoArticleRepo) DeleteArticle(ctx context.Context, id string) (*models.Article, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
+// statusFilter matches any of the statuses, articles without status count as published
+func statusFilter(statuses ...models.Status) bson.M {
+	in := bson.A{}
+	for _, s := range statuses {
+		in = append(in, s)
+		if s == models.StatusPublished {
+			in = append(in, nil)
+		}
+	}
+	return bson.M{"$in": in}
+}
+
+// updateAttempts limits retries of updates without expected version when the article changes concurrently
+const updateAttempts = 3
+
+// UpdateArticle attempts to update listed fields of an article
+// The revision is written before the update, which is conditioned on the version the revision was made of
+func (r *MongoArticleRepo) UpdateArticle(ctx context.Context, a *models.Article, fields []string) (*models.Article, error) {
+	set := bson.M{}
+	for _, f := range fields {
+		v, ok := a.Field(f)
+		if !ok {
+			return nil, fmt.Errorf("Unknown field %v", f)
+		}
+		set[f] = v
+	}
+	for attempt := 1; ; attempt++ {
+		old := models.Article{}
+		if err := r.c.FindOne(ctx, versionFilter(a.ID, a.Version)).Decode(&old); err != nil {
+			return nil, mongoError(KindArticle, a.ID.Hex(), r.versionConflict(ctx, a.ID, a.Version, err))
+		}
+		if touchesContent(fields) {
+			// the update applies only to the version read above, so the summary is known before it
+			if err := summarize(set, old, a, fields); err != nil {
+				return nil, err
+			}
+		}
+		rev := models.NewRevision(old)
+		if _, err := r.revisions.InsertOne(ctx, rev); err != nil {
+			return nil, err
+		}
+		set["update_time"] = models.Now()
+		m := models.Article{}
+		err := r.c.FindOneAndUpdate(ctx,
+			versionFilter(a.ID, old.Version),
+			bson.M{"$set": set, "$inc": bson.M{"version": 1}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&m)
+		if err == nil {
+			return &m, nil
+		}
+		// the article wasn't updated, so the revision of its version is not needed
+		if _, derr := r.revisions.DeleteOne(ctx, bson.M{"_id": rev.ID}); derr != nil {
+			log.Printf("Error removing revision %v of not updated article: %v", rev.ID.Hex(), derr)
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) || a.Version != 0 || attempt == updateAttempts {
+			return nil, mongoError(KindArticle, a.ID.Hex(), r.versionConflict(ctx, a.ID, a.Version, err))
+		}
+		// changed since it was read, the update without expected version goes on top of the new version
+	}
+}
+
+// BackfillSummaries derives summary fields of Articles stored before they were introduced
+// returns the number of updated Articles, ones which content was updated meanwhile keep the summary of the update
+func (r *MongoArticleRepo) BackfillSummaries(ctx context.Context) (int64, error) {
+	missing := bson.M{"word_count": bson.M{"$exists": false}}
+	c, err := r.c.Find(ctx, missing)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close(ctx)
+	n := int64(0)
+	for c.Next(ctx) {
+		m := models.Article{}
+		if err := c.Decode(&m); err != nil {
+			return n, err
+		}
+		if err := m.Summarize(); err != nil {
+			log.Printf("Error summarizing article %v: %v", m.ID.Hex(), err)
+			continue
+		}
+		res, err := r.c.UpdateOne(ctx,
+			bson.M{"_id": m.ID, "word_count": missing["word_count"]},
+			bson.M{"$set": bson.M{"excerpt": m.Excerpt, "word_count": m.WordCount, "reading_minutes": m.ReadingMinutes}})
+		if err != nil {
+			return n, err
+		}
+		n += res.ModifiedCount
+	}
+	return n, c.Err()
+}
+
+// summarize adds summary fields of the Article with listed fields of a copied over old to the update
+func summarize(set bson.M, old models.Article, a *models.Article, fields []string) error {
+	for _, f := range fields {
+		old.CopyField(*a, f)
+	}
+	if err := old.Summarize(); err != nil {
+		return err
+	}
+	set["excerpt"] = old.Excerpt
+	set["word_count"] = old.WordCount
+	set["reading_minutes"] = old.ReadingMinutes
+	return nil
+}
+
+// versionFilter matches a live article by ID and version, zero version matches any
+func versionFilter(id primitive.ObjectID, version int64) bson.M {
+	filter := liveByID(id)
+	if version != 0 {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// versionConflict turns a failed lookup by ID and version into *VersionConflictError
+// when the article exists with another version, otherwise returns err
+func (r *MongoArticleRepo) versionConflict(ctx context.Context, id primitive.ObjectID, version int64, err error) error {
+	if version == 0 || !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	cur := models.Article{}
+	if ferr := r.c.FindOne(ctx, liveByID(id), options.FindOne().SetProjection(bson.M{"version": 1})).Decode(&cur); ferr != nil {
+		return err
+	}
+	return &VersionConflictError{ID: id.Hex(), Expected: version, Current: cur.Version}
+}
+
+// DeleteArticle attempts to move article to trash by object id
+func (r *MongoArticleRepo) DeleteArticle(ctx context.Context, id string, version int64) (*models.Article, error) {
+	oid, err := parseID(KindArticle, id)
 	if err != nil {
 		return nil, err
 	}
-	res := r.c.FindOneAndDelete(ctx, bson.M{"_id": oid})
+	now := models.Now()
+	res := r.c.FindOneAndUpdate(ctx,
+		versionFilter(oid, version),
+		bson.M{"$set": bson.M{"delete_time": now, "update_time": now}, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After))
 	m := models.Article{}
 	err = res.Decode(&m)
+	if err != nil {
+		return nil, mongoError(KindArticle, id, r.versionConflict(ctx, oid, version, err))
+	}
+	return &m, nil
+}
+
+// DeleteArticles moves articles to trash with a single UpdateMany
+func (r *MongoArticleRepo) DeleteArticles(ctx context.Context, ids []string) (map[string]models.Article, error) {
+	oids, err := objectIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	now := models.Now()
+	_, err = r.c.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": oids}, "delete_time": notDeleted},
+		bson.M{"$set": bson.M{"delete_time": now, "update_time": now}, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return nil, err
+	}
+	// articles deleted by this call are the ones with its delete time
+	return r.findArticles(ctx, bson.M{"_id": bson.M{"$in": oids}, "delete_time": now})
+}
+
+// UndeleteArticle attempts to restore article from trash by object id
+func (r *MongoArticleRepo) UndeleteArticle(ctx context.Context, id string) (*models.Article, error) {
+	oid, err := parseID(KindArticle, id)
 	if err != nil {
 		return nil, err
 	}
+	res := r.c.FindOneAndUpdate(ctx,
+		bson.M{"_id": oid, "delete_time": bson.M{"$exists": true}},
+		bson.M{
+			"$set":   bson.M{"update_time": models.Now()},
+			"$unset": bson.M{"delete_time": ""},
+			"$inc":   bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After))
+	m := models.Article{}
+	if err := res.Decode(&m); err != nil {
+		return nil, mongoError(KindArticle, id, err)
+	}
 	return &m, nil
 }
 
+// PurgeDeleted removes articles which stayed in trash long enough together with their revisions
+func (r *MongoArticleRepo) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	due := bson.M{"delete_time": bson.M{"$lte": before}}
+	ids, err := r.articleIDs(ctx, due)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	// the cutoff is checked again, articles restored from trash since they were found must stay
+	if _, err := r.c.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "delete_time": bson.M{"$lte": before}}); err != nil {
+		return nil, err
+	}
+	left, err := r.articleIDs(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	kept := make(map[primitive.ObjectID]bool, len(left))
+	for _, id := range left {
+		kept[id.(primitive.ObjectID)] = true
+	}
+	purged := make(bson.A, 0, len(ids))
+	hexIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if oid := id.(primitive.ObjectID); !kept[oid] {
+			purged = append(purged, oid)
+			hexIDs = append(hexIDs, oid.Hex())
+		}
+	}
+	if len(purged) == 0 {
+		return nil, nil
+	}
+	if _, err := r.revisions.DeleteMany(ctx, bson.M{"article_id": bson.M{"$in": purged}}); err != nil {
+		return hexIDs, fmt.Errorf("Articles %v were purged but their revisions were not: %w", hexIDs, err)
+	}
+	return hexIDs, nil
+}
+
+// articleIDs returns IDs of articles matching the filter
+func (r *MongoArticleRepo) articleIDs(ctx context.Context, filter bson.M) (bson.A, error) {
+	c, err := r.c.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	found := []models.Article{}
+	if err := c.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	ids := make(bson.A, 0, len(found))
+	for _, a := range found {
+		ids = append(ids, a.ID)
+	}
+	return ids, nil
+}
+
+// GetArticles gets live articles by IDs with $in
+func (r *MongoArticleRepo) GetArticles(ctx context.Context, ids []string) (map[string]models.Article, error) {
+	oids, err := objectIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	return r.findArticles(ctx, bson.M{"_id": bson.M{"$in": oids}, "delete_time": notDeleted})
+}
+
+// GetArticlesByKey gets articles including ones in trash by values of a unique key with $in
+func (r *MongoArticleRepo) GetArticlesByKey(ctx context.Context, key string, values []string) (map[string]models.Article, error) {
+	if key != KeySlug && key != KeyExternalID {
+		return nil, fmt.Errorf("Unknown key %v", key)
+	}
+	found, err := r.findArticles(ctx, bson.M{key: bson.M{"$in": values}})
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]models.Article, len(found))
+	for _, a := range found {
+		if key == KeySlug {
+			res[a.Slug] = a
+		} else {
+			res[a.ExternalID] = a
+		}
+	}
+	return res, nil
+}
+
+// findArticles returns articles matching the filter by ID
+func (r *MongoArticleRepo) findArticles(ctx context.Context, filter bson.M) (map[string]models.Article, error) {
+	c, err := r.c.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := []models.Article{}
+	if err := c.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	res := make(map[string]models.Article, len(list))
+	for _, a := range list {
+		res[a.ID.Hex()] = a
+	}
+	return res, nil
+}
+
+// objectIDs parses hex IDs
+func objectIDs(ids []string) (bson.A, error) {
+	oids := make(bson.A, 0, len(ids))
+	for _, id := range ids {
+		oid, err := parseID(KindArticle, id)
+		if err != nil {
+			return nil, err
+		}
+		oids = append(oids, oid)
+	}
+	return oids, nil
+}
+
 // GetArticle gets an article by ID
 func (r *MongoArticleRepo) GetArticle(ctx context.Context, id string) (*models.Article, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
+	oid, err := parseID(KindArticle, id)
+	if err != nil {
+		return nil, err
+	}
+	res := r.c.FindOne(ctx, liveByID(oid))
+	m := models.Article{}
+	err = res.Decode(&m)
+	if err != nil {
+		return nil, mongoError(KindArticle, id, err)
+	}
+	return &m, nil
+}
+
+// SetStatus changes status and publish time of an article
+func (r *MongoArticleRepo) SetStatus(ctx context.Context, id string, s models.Status, publishTime time.Time) (*models.Article, error) {
+	oid, err := parseID(KindArticle, id)
 	if err != nil {
 		return nil, err
 	}
-	res := r.c.FindOne(ctx, bson.M{"_id": oid})
+	update := bson.M{
+		"$set": bson.M{"status": s, "publish_time": publishTime, "update_time": models.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+	if publishTime.IsZero() {
+		update = bson.M{
+			"$set":   bson.M{"status": s, "update_time": models.Now()},
+			"$unset": bson.M{"publish_time": ""},
+			"$inc":   bson.M{"version": 1},
+		}
+	}
+	res := r.c.FindOneAndUpdate(ctx, liveByID(oid), update, options.FindOneAndUpdate().SetReturnDocument(options.After))
 	m := models.Article{}
 	err = res.Decode(&m)
+	if err != nil {
+		return nil, mongoError(KindArticle, id, err)
+	}
+	return &m, nil
+}
+
+// Archive archives an article with a pipeline update, so the publish time is removed only from drafts
+func (r *MongoArticleRepo) Archive(ctx context.Context, id string) (*models.Article, error) {
+	oid, err := parseID(KindArticle, id)
+	if err != nil {
+		return nil, err
+	}
+	res := r.c.FindOneAndUpdate(ctx, liveByID(oid), mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status":      models.StatusArchived,
+			"update_time": models.Now(),
+			"version":     bson.M{"$add": bson.A{"$version", 1}},
+			"publish_time": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$status", models.StatusDraft}}, "$$REMOVE", "$publish_time",
+			}},
+		}}},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	m := models.Article{}
+	if err := res.Decode(&m); err != nil {
+		return nil, mongoError(KindArticle, id, err)
+	}
+	return &m, nil
+}
+
+// PublishScheduled publishes due drafts with a single update
+func (r *MongoArticleRepo) PublishScheduled(ctx context.Context, now time.Time) (int64, error) {
+	res, err := r.c.UpdateMany(ctx,
+		bson.M{"status": models.StatusDraft, "publish_time": bson.M{"$lte": now}, "delete_time": notDeleted},
+		bson.M{
+			"$set": bson.M{"status": models.StatusPublished, "update_time": models.Now()},
+			"$inc": bson.M{"version": 1},
+		})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// GetArticleBySlug gets an article by its slug
+func (r *MongoArticleRepo) GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	res := r.c.FindOne(ctx, bson.M{"slug": slug, "status": statusFilter(models.StatusPublished), "delete_time": notDeleted})
+	m := models.Article{}
+	err := res.Decode(&m)
+	if err != nil {
+		return nil, mongoError(KindArticle, slug, err)
+	}
+	return &m, nil
+}
+
+// SearchArticles uses MongoDB text index to find articles
+func (r *MongoArticleRepo) SearchArticles(ctx context.Context, terms []string, limit int64) ([]SearchResult, error) {
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	filter := bson.M{
+		"$text":       bson.M{"$search": strings.Join(terms, " ")},
+		"status":      statusFilter(models.StatusPublished),
+		"delete_time": notDeleted,
+	}
+	c, err := r.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	res := []SearchResult{}
+	if err := c.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListTags counts articles per tag with aggregation pipeline
+func (r *MongoArticleRepo) ListTags(ctx context.Context) ([]TagCount, error) {
+	c, err := r.c.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": statusFilter(models.StatusPublished), "delete_time": notDeleted}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := []TagCount{}
+	if err := c.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListRevisions returns revisions of an article, newest first
+func (r *MongoArticleRepo) ListRevisions(ctx context.Context, articleID string) ([]models.Revision, error) {
+	oid, err := parseID(KindArticle, articleID)
 	if err != nil {
 		return nil, err
 	}
+	c, err := r.revisions.Find(ctx, bson.M{"article_id": oid}, options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	res := []models.Revision{}
+	if err := c.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetRevision gets a revision of an article
+func (r *MongoArticleRepo) GetRevision(ctx context.Context, articleID string, revisionID string) (*models.Revision, error) {
+	aid, err := parseID(KindArticle, articleID)
+	if err != nil {
+		return nil, err
+	}
+	rid, err := parseID(KindRevision, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	res := r.revisions.FindOne(ctx, bson.M{"_id": rid, "article_id": aid})
+	m := models.Revision{}
+	if err := res.Decode(&m); err != nil {
+		return nil, mongoError(KindRevision, revisionID, err)
+	}
 	return &m, nil
 }
+
+// changeEvent is the part of a change stream event the repo needs
+type changeEvent struct {
+	OperationType string         `bson:"operationType"`
+	FullDocument  models.Article `bson:"fullDocument"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	UpdateDescription struct {
+		UpdatedFields bson.M `bson:"updatedFields"`
+	} `bson:"updateDescription"`
+}
+
+// WatchArticles sends changes of articles using a change stream, which needs a replica set
+// Updated articles are looked up when the event is read, so they may include later changes
+func (r *MongoArticleRepo) WatchArticles(ctx context.Context, token string, out chan<- ArticleEvent) error {
+	defer close(out)
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if token != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || bson.Raw(raw).Validate() != nil {
+			return ErrInvalidResumeToken
+		}
+		opts.SetResumeAfter(bson.Raw(raw))
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}}}},
+	}
+	cs, err := r.c.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	defer cs.Close(context.Background())
+	for cs.Next(ctx) {
+		ce := changeEvent{}
+		if err := cs.Decode(&ce); err != nil {
+			return err
+		}
+		e := ArticleEvent{Article: ce.FullDocument, Token: base64.RawURLEncoding.EncodeToString(cs.ResumeToken())}
+		switch ce.OperationType {
+		case "insert":
+			e.Type = EventCreated
+		case "delete":
+			e.Type = EventDeleted
+			e.Article = models.Article{ID: ce.DocumentKey.ID}
+		default:
+			e.Type = EventUpdated
+			if _, ok := ce.UpdateDescription.UpdatedFields["delete_time"]; ok {
+				e.Type = EventDeleted
+			}
+			if e.Article.ID.IsZero() {
+				// the article was purged before it could be looked up
+				continue
+			}
+		}
+		select {
+		case out <- e:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return cs.Err()
+}
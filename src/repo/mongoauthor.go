@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"example.com/grpc/blog/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAuthorRepo is the Author repository implementation in MongoDB
+type MongoAuthorRepo struct {
+	c *mongo.Collection
+}
+
+// NewMongoAuthorRepo returns initialized MongoDB author repo
+func NewMongoAuthorRepo(c *mongo.Client) *MongoAuthorRepo {
+	return &MongoAuthorRepo{
+		c: c.Database(os.Getenv("DB")).Collection("authors"),
+	}
+}
+
+// AddAuthor adds an author
+func (r *MongoAuthorRepo) AddAuthor(ctx context.Context, a *models.Author) (string, error) {
+	a.ID = primitive.NewObjectID()
+	a.CreateTime = models.Now()
+	a.UpdateTime = a.CreateTime
+	if _, err := r.c.InsertOne(ctx, a); err != nil {
+		return "", err
+	}
+	return a.ID.Hex(), nil
+}
+
+// GetAuthor gets an author by ID
+func (r *MongoAuthorRepo) GetAuthor(ctx context.Context, id string) (*models.Author, error) {
+	oid, err := parseID(KindAuthor, id)
+	if err != nil {
+		return nil, err
+	}
+	m := models.Author{}
+	if err := r.c.FindOne(ctx, bson.M{"_id": oid}).Decode(&m); err != nil {
+		return nil, mongoError(KindAuthor, id, err)
+	}
+	return &m, nil
+}
+
+// UpdateAuthor updates listed fields of an author
+func (r *MongoAuthorRepo) UpdateAuthor(ctx context.Context, a *models.Author, fields []string) (*models.Author, error) {
+	set := bson.M{}
+	for _, f := range fields {
+		v, ok := a.Field(f)
+		if !ok {
+			return nil, fmt.Errorf("Unknown field %v", f)
+		}
+		set[f] = v
+	}
+	set["update_time"] = models.Now()
+	res := r.c.FindOneAndUpdate(ctx,
+		bson.M{"_id": a.ID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After))
+	m := models.Author{}
+	if err := res.Decode(&m); err != nil {
+		return nil, mongoError(KindAuthor, a.ID.Hex(), err)
+	}
+	return &m, nil
+}
+
+// DeleteAuthor deletes an author by ID
+func (r *MongoAuthorRepo) DeleteAuthor(ctx context.Context, id string) (*models.Author, error) {
+	oid, err := parseID(KindAuthor, id)
+	if err != nil {
+		return nil, err
+	}
+	m := models.Author{}
+	if err := r.c.FindOneAndDelete(ctx, bson.M{"_id": oid}).Decode(&m); err != nil {
+		return nil, mongoError(KindAuthor, id, err)
+	}
+	return &m, nil
+}
+
+// ListAuthors lists authors ordered by ID
+func (r *MongoAuthorRepo) ListAuthors(ctx context.Context, after primitive.ObjectID, limit int64) ([]models.Author, error) {
+	filter := bson.M{}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	c, err := r.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	res := []models.Author{}
+	if err := c.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"context"
+	"os"
+
+	"example.com/grpc/blog/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCommentRepo is the Comment repository implementation in MongoDB
+type MongoCommentRepo struct {
+	c *mongo.Collection
+}
+
+// NewMongoCommentRepo returns initialized MongoDB comment repo
+func NewMongoCommentRepo(c *mongo.Client) *MongoCommentRepo {
+	return &MongoCommentRepo{
+		c: c.Database(os.Getenv("DB")).Collection("comments"),
+	}
+}
+
+// EnsureIndexes creates indexes the repo relies on
+func (r *MongoCommentRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.c.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// comments are listed per article in creation order
+			Keys:    bson.D{{Key: "article_id", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("comment_article_id"),
+		},
+		{
+			// used to find replies of a comment
+			Keys:    bson.D{{Key: "ancestors", Value: 1}},
+			Options: options.Index().SetName("comment_ancestors"),
+		},
+	})
+	return err
+}
+
+// AddComment adds a comment
+func (r *MongoCommentRepo) AddComment(ctx context.Context, c *models.Comment) (string, error) {
+	c.ID = primitive.NewObjectID()
+	c.CreateTime = models.Now()
+	_, err := r.c.InsertOne(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	return c.ID.Hex(), nil
+}
+
+// GetComment gets a comment by ID
+func (r *MongoCommentRepo) GetComment(ctx context.Context, id string) (*models.Comment, error) {
+	oid, err := parseID(KindComment, id)
+	if err != nil {
+		return nil, err
+	}
+	m := models.Comment{}
+	if err := r.c.FindOne(ctx, bson.M{"_id": oid}).Decode(&m); err != nil {
+		return nil, mongoError(KindComment, id, err)
+	}
+	return &m, nil
+}
+
+// DeleteComment deletes a comment by ID with all replies to it
+func (r *MongoCommentRepo) DeleteComment(ctx context.Context, id string) (int64, error) {
+	oid, err := parseID(KindComment, id)
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.c.DeleteMany(ctx, bson.M{"$or": bson.A{bson.M{"_id": oid}, bson.M{"ancestors": oid}}})
+	if err != nil {
+		return 0, err
+	}
+	if res.DeletedCount == 0 {
+		return 0, &Error{Kind: KindComment, Key: id, Err: ErrNotFound}
+	}
+	return res.DeletedCount, nil
+}
+
+// DeleteArticleComments deletes every comment of the articles
+func (r *MongoCommentRepo) DeleteArticleComments(ctx context.Context, articleIDs []string) (int64, error) {
+	ids, err := objectIDs(articleIDs)
+	if err != nil {
+		return 0, err
+	}
+	res, err := r.c.DeleteMany(ctx, bson.M{"article_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// FillComments fills the channel with comments
+func (r *MongoCommentRepo) FillComments(ctx context.Context, q CommentQuery, out chan<- models.Comment, stop <-chan struct{}) error {
+	defer close(out)
+	filter := bson.M{"article_id": q.ArticleID}
+	if !q.ParentID.IsZero() {
+		filter["ancestors"] = q.ParentID
+	}
+	if !q.After.IsZero() {
+		filter["_id"] = bson.M{"$gt": q.After}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if q.Limit > 0 {
+		opts.SetLimit(q.Limit)
+	}
+	c, err := r.c.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer c.Close(ctx)
+	for c.Next(ctx) {
+		m := models.Comment{}
+		if err := c.Decode(&m); err != nil {
+			return err
+		}
+		select {
+		case out <- m:
+		case <-stop:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return c.Err()
+}
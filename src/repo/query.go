@@ -0,0 +1,256 @@
+package repo
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/slug"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ArticleQuery describes which Articles FillArticles should return
+type ArticleQuery struct {
+	Filter ArticleFilter
+	Sort   SortField
+	// Desc reverses the order
+	Desc bool
+	// After is the position of the last Article of the previous page, nil means from the start
+	After *Cursor
+	// Limit is the maximum number of Articles to return, 0 means no limit
+	Limit int64
+	// Summary leaves the content out of returned Articles
+	Summary bool
+}
+
+// SearchResult is an Article found by full-text search with its relevance score
+type SearchResult struct {
+	Article models.Article `bson:",inline"`
+	Score   float64        `bson:"score"`
+}
+
+// freeSlug returns the first variant of the slug which is not taken
+func freeSlug(base string, taken func(string) bool) string {
+	for n := 1; ; n++ {
+		if s := slug.WithSuffix(base, n); !taken(s) {
+			return s
+		}
+	}
+}
+
+// Unique keys of Articles besides ID, named after their bson keys
+const (
+	KeySlug       = "slug"
+	KeyExternalID = "external_id"
+)
+
+// touchesContent checks whether updating the fields changes what Summarize derives
+func touchesContent(fields []string) bool {
+	for _, f := range fields {
+		if f == models.FieldContent || f == models.FieldContentFormat {
+			return true
+		}
+	}
+	return false
+}
+
+// TagCount is a tag with the number of Articles having it
+type TagCount struct {
+	Tag   string `bson:"_id"`
+	Count int64  `bson:"count"`
+}
+
+// sortTagCounts orders tags by count, most used first, then by name
+func sortTagCounts(tags []TagCount) {
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+}
+
+// ArticleFilter narrows down the Articles returned by FillArticles
+// Empty fields are not applied
+type ArticleFilter struct {
+	// AuthorID must be equal to Article.AuthorID
+	AuthorID string
+	// TitlePrefix is a case-sensitive prefix of Article.Title
+	TitlePrefix string
+	// TitleContains is a case-insensitive substring of Article.Title
+	TitleContains string
+	// ContentContains is a case-insensitive substring of Article.Content
+	ContentContains string
+	// Tags must all be present in Article.Tags
+	Tags []string
+	// Statuses must contain the current status of Article
+	Statuses []models.Status
+	// Deleted selects Articles in trash instead of live ones
+	Deleted bool
+}
+
+// matches checks whether Article a passes the filter
+func (f ArticleFilter) matches(a models.Article) bool {
+	if a.IsDeleted() != f.Deleted {
+		return false
+	}
+	if f.AuthorID != "" && a.AuthorID != f.AuthorID {
+		return false
+	}
+	if f.TitlePrefix != "" && !strings.HasPrefix(a.Title, f.TitlePrefix) {
+		return false
+	}
+	if f.TitleContains != "" && !containsFold(a.Title, f.TitleContains) {
+		return false
+	}
+	if f.ContentContains != "" && !containsFold(a.Content, f.ContentContains) {
+		return false
+	}
+	for _, t := range f.Tags {
+		if !hasTag(a, t) {
+			return false
+		}
+	}
+	if len(f.Statuses) > 0 && !hasStatus(a, f.Statuses) {
+		return false
+	}
+	return true
+}
+
+// hasStatus checks whether Article a has one of the statuses
+func hasStatus(a models.Article, statuses []models.Status) bool {
+	for _, s := range statuses {
+		if a.CurrentStatus() == s {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag checks whether Article a is tagged with t
+func hasTag(a models.Article, t string) bool {
+	for _, at := range a.Tags {
+		if at == t {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold is case-insensitive strings.Contains
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// SortField is the Article field FillArticles orders by
+// Ties are always broken by ID in the same direction
+type SortField int
+
+// Fields Articles can be sorted by
+const (
+	SortByID SortField = iota
+	SortByTitle
+	SortByAuthor
+	SortByCreateTime
+	SortByUpdateTime
+	// SortByPublishTime is meant for published Articles, unscheduled drafts have no publish time
+	SortByPublishTime
+)
+
+// timeKeyLayout formats times into keys which can be compared as strings
+const timeKeyLayout = "2006-01-02T15:04:05.000000000Z"
+
+// key returns the value of sort field for Article a, ID has no key
+func (f SortField) key(a models.Article) string {
+	switch f {
+	case SortByTitle:
+		return a.Title
+	case SortByAuthor:
+		return a.AuthorID
+	case SortByCreateTime:
+		return a.CreateTime.UTC().Format(timeKeyLayout)
+	case SortByUpdateTime:
+		return a.UpdateTime.UTC().Format(timeKeyLayout)
+	case SortByPublishTime:
+		return a.PublishTime.UTC().Format(timeKeyLayout)
+	}
+	return ""
+}
+
+// bsonKey returns the document key of the sort field, empty for ID
+func (f SortField) bsonKey() string {
+	switch f {
+	case SortByTitle:
+		return "title"
+	case SortByAuthor:
+		return "author_id"
+	case SortByCreateTime:
+		return "create_time"
+	case SortByUpdateTime:
+		return "update_time"
+	case SortByPublishTime:
+		return "publish_time"
+	}
+	return ""
+}
+
+// bsonValue converts the key back to the value stored in MongoDB
+func (f SortField) bsonValue(key string) (interface{}, error) {
+	switch f {
+	case SortByCreateTime, SortByUpdateTime, SortByPublishTime:
+		return time.Parse(timeKeyLayout, key)
+	}
+	return key, nil
+}
+
+// compare orders a before b with negative result and after b with positive one
+func (q ArticleQuery) compare(key string, id primitive.ObjectID, b models.Article) int {
+	c := strings.Compare(key, q.Sort.key(b))
+	if c == 0 {
+		c = bytes.Compare(id[:], b.ID[:])
+	}
+	if q.Desc {
+		return -c
+	}
+	return c
+}
+
+// less is used to sort Articles in memory
+func (q ArticleQuery) less(a, b models.Article) bool {
+	return q.compare(q.Sort.key(a), a.ID, b) < 0
+}
+
+// isAfterCursor checks whether Article a comes after the query cursor
+func (q ArticleQuery) isAfterCursor(a models.Article) bool {
+	if q.After == nil {
+		return true
+	}
+	return q.compare(q.After.Key, q.After.ID, a) < 0
+}
+
+// Cursor points to an Article inside the ordered result of FillArticles
+type Cursor struct {
+	ID primitive.ObjectID
+	// Key is the value of the sort field
+	Key string
+	// Sort and Desc are the order the Cursor was made for, it points nowhere in another one
+	Sort SortField
+	Desc bool
+}
+
+// CursorFor returns the Cursor pointing to Article a in the order of the query
+func (q ArticleQuery) CursorFor(a models.Article) Cursor {
+	return Cursor{ID: a.ID, Key: q.Sort.key(a), Sort: q.Sort, Desc: q.Desc}
+}
+
+// CheckCursor makes sure the Cursor was made for the order of the query and its key is a value of the sort field
+func (q ArticleQuery) CheckCursor(c Cursor) error {
+	if c.Sort != q.Sort || c.Desc != q.Desc {
+		return fmt.Errorf("Cursor of sort %v (descending %v) can't be used with sort %v (descending %v)", c.Sort, c.Desc, q.Sort, q.Desc)
+	}
+	_, err := q.Sort.bsonValue(c.Key)
+	return err
+}
@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+	"sort"
+
+	"example.com/grpc/blog/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// monthLayout formats create time into keys of Stats.ByMonth
+const monthLayout = "2006-01"
+
+// Stats counts live Articles and their words
+type Stats struct {
+	Articles int64
+	Words    int64
+	// ByAuthor and ByTag are ordered by the number of Articles, most first, then by key
+	ByAuthor []StatsBucket
+	ByTag    []StatsBucket
+	// ByMonth is keyed by create time month in UTC, oldest first
+	ByMonth []StatsBucket
+}
+
+// StatsBucket counts Articles sharing the key
+type StatsBucket struct {
+	Key      string `bson:"_id"`
+	Articles int64  `bson:"articles"`
+	Words    int64  `bson:"words"`
+}
+
+// sortByArticles orders buckets by the number of Articles, most first, then by key
+func sortByArticles(b []StatsBucket) {
+	sort.Slice(b, func(i, j int) bool {
+		if b[i].Articles != b[j].Articles {
+			return b[i].Articles > b[j].Articles
+		}
+		return b[i].Key < b[j].Key
+	})
+}
+
+// GetStats by scanning every Article
+func (m *MapArticleRepo) GetStats(ctx context.Context) (*Stats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	res := &Stats{}
+	authors := make(map[string]*StatsBucket)
+	tags := make(map[string]*StatsBucket)
+	months := make(map[string]*StatsBucket)
+	count := func(buckets map[string]*StatsBucket, key string, a models.Article) {
+		b, ok := buckets[key]
+		if !ok {
+			b = &StatsBucket{Key: key}
+			buckets[key] = b
+		}
+		b.Articles++
+		b.Words += a.WordCount
+	}
+	for _, a := range m.articles {
+		if a.IsDeleted() {
+			continue
+		}
+		res.Articles++
+		res.Words += a.WordCount
+		count(authors, a.AuthorID, a)
+		for _, t := range a.Tags {
+			count(tags, t, a)
+		}
+		count(months, a.CreateTime.UTC().Format(monthLayout), a)
+	}
+	res.ByAuthor = statsBuckets(authors)
+	sortByArticles(res.ByAuthor)
+	res.ByTag = statsBuckets(tags)
+	sortByArticles(res.ByTag)
+	res.ByMonth = statsBuckets(months)
+	sort.Slice(res.ByMonth, func(i, j int) bool { return res.ByMonth[i].Key < res.ByMonth[j].Key })
+	return res, nil
+}
+
+func statsBuckets(m map[string]*StatsBucket) []StatsBucket {
+	res := make([]StatsBucket, 0, len(m))
+	for _, b := range m {
+		res = append(res, *b)
+	}
+	return res
+}
+
+// statsGroup counts Articles and words grouped by the key expression
+func statsGroup(key interface{}) bson.D {
+	return bson.D{{Key: "$group", Value: bson.M{
+		"_id":      key,
+		"articles": bson.M{"$sum": 1},
+		"words":    bson.M{"$sum": "$word_count"},
+	}}}
+}
+
+// byArticles sorts groups the way sortByArticles does
+var byArticles = bson.D{{Key: "$sort", Value: bson.D{{Key: "articles", Value: -1}, {Key: "_id", Value: 1}}}}
+
+// GetStats with a single aggregation, every facet is a separate pipeline over live articles
+func (r *MongoArticleRepo) GetStats(ctx context.Context) (*Stats, error) {
+	c, err := r.c.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"delete_time": notDeleted}}},
+		{{Key: "$facet", Value: bson.M{
+			"total":   bson.A{statsGroup(nil)},
+			"authors": bson.A{statsGroup("$author_id"), byArticles},
+			"tags":    bson.A{bson.D{{Key: "$unwind", Value: "$tags"}}, statsGroup("$tags"), byArticles},
+			"months": bson.A{
+				statsGroup(bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$create_time"}}),
+				bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+			},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	facets := []struct {
+		Total   []StatsBucket `bson:"total"`
+		Authors []StatsBucket `bson:"authors"`
+		Tags    []StatsBucket `bson:"tags"`
+		Months  []StatsBucket `bson:"months"`
+	}{}
+	if err := c.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+	res := &Stats{ByAuthor: []StatsBucket{}, ByTag: []StatsBucket{}, ByMonth: []StatsBucket{}}
+	if len(facets) == 0 {
+		return res, nil
+	}
+	f := facets[0]
+	// total has no group when there are no articles
+	if len(f.Total) > 0 {
+		res.Articles = f.Total[0].Articles
+		res.Words = f.Total[0].Words
+	}
+	res.ByAuthor = append(res.ByAuthor, f.Authors...)
+	res.ByTag = append(res.ByTag, f.Tags...)
+	res.ByMonth = append(res.ByMonth, f.Months...)
+	return res, nil
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"example.com/grpc/blog/src/models"
+)
+
+// EventType is the kind of change of an Article
+type EventType int
+
+// Article changes, moving to trash and purging both count as deletion
+const (
+	EventCreated EventType = iota + 1
+	EventUpdated
+	EventDeleted
+)
+
+// ArticleEvent is a change of an Article
+type ArticleEvent struct {
+	Type EventType
+	// Article after the change, purged Articles have only ID set
+	Article models.Article
+	// Token resumes watching right after the event
+	Token string
+}
+
+var (
+	// ErrInvalidResumeToken is returned by WatchArticles when watching can't be resumed from the token
+	ErrInvalidResumeToken = errors.New("Resume token is invalid or expired")
+	// ErrWatcherBehind is returned by WatchArticles when changes were not read fast enough
+	ErrWatcherBehind = errors.New("Watcher fell behind changes")
+)
+
+// MapEventsKept is the number of recent events MapArticleRepo keeps to resume watching from
+var MapEventsKept = 1000
+
+// mapEventsBuffer is the number of events a watcher of MapArticleRepo can fall behind by
+const mapEventsBuffer = 100
+
+// eventHub is the in-process pub/sub of Article changes
+type eventHub struct {
+	mu   sync.Mutex
+	seq  int64
+	kept []ArticleEvent
+	subs map[chan ArticleEvent]bool
+}
+
+// publish sends the event to every watcher, watchers which fell behind are dropped
+func (h *eventHub) publish(t EventType, a models.Article) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	e := ArticleEvent{Type: t, Article: a, Token: strconv.FormatInt(h.seq, 10)}
+	h.kept = append(h.kept, e)
+	if len(h.kept) > MapEventsKept {
+		h.kept = h.kept[len(h.kept)-MapEventsKept:]
+	}
+	for sub := range h.subs {
+		select {
+		case sub <- e:
+		default:
+			delete(h.subs, sub)
+			close(sub)
+		}
+	}
+}
+
+// subscribe returns a channel of new events and kept events which go after the token
+func (h *eventHub) subscribe(token string) (chan ArticleEvent, []ArticleEvent, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var backlog []ArticleEvent
+	if token != "" {
+		seq, err := strconv.ParseInt(token, 10, 64)
+		if err != nil || seq > h.seq {
+			return nil, nil, ErrInvalidResumeToken
+		}
+		// every event after the token must still be kept
+		first := h.seq - int64(len(h.kept)) + 1
+		if seq < first-1 {
+			return nil, nil, ErrInvalidResumeToken
+		}
+		backlog = append(backlog, h.kept[seq-first+1:]...)
+	}
+	if h.subs == nil {
+		h.subs = make(map[chan ArticleEvent]bool)
+	}
+	sub := make(chan ArticleEvent, mapEventsBuffer)
+	h.subs[sub] = true
+	return sub, backlog, nil
+}
+
+// unsubscribe stops sending events to the channel
+func (h *eventHub) unsubscribe(sub chan ArticleEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[sub] {
+		delete(h.subs, sub)
+		close(sub)
+	}
+}
+
+// WatchArticles sends changes of Articles in the map to the channel until the context is done
+func (m *MapArticleRepo) WatchArticles(ctx context.Context, token string, out chan<- ArticleEvent) error {
+	defer close(out)
+	sub, backlog, err := m.events.subscribe(token)
+	if err != nil {
+		return err
+	}
+	defer m.events.unsubscribe(sub)
+	for _, e := range backlog {
+		select {
+		case out <- e:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	for {
+		select {
+		case e, ok := <-sub:
+			if !ok {
+				return ErrWatcherBehind
+			}
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
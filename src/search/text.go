@@ -0,0 +1,126 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Highlight marks wrapping matched terms inside snippets
+const (
+	HighlightStart = "<em>"
+	HighlightEnd   = "</em>"
+)
+
+// Token is one word of a text with its byte offsets
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokens splits text into lowercase words of letters and digits
+func Tokens(s string) []Token {
+	tokens := []Token{}
+	start := -1
+	for i, r := range s {
+		word := unicode.IsLetter(r) || unicode.IsNumber(r)
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			tokens = append(tokens, Token{Term: strings.ToLower(s[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Term: strings.ToLower(s[start:]), Start: start, End: len(s)})
+	}
+	return tokens
+}
+
+// Terms returns unique terms of the text in order of appearance
+func Terms(s string) []string {
+	seen := make(map[string]bool)
+	terms := []string{}
+	for _, t := range Tokens(s) {
+		if !seen[t.Term] {
+			seen[t.Term] = true
+			terms = append(terms, t.Term)
+		}
+	}
+	return terms
+}
+
+// Highlight wraps all matched terms of the HTML escaped text with highlight marks
+func Highlight(s string, terms []string) string {
+	return mark(s, Tokens(s), termSet(terms), 0, len(s))
+}
+
+// Snippet cuts a window of around "words" tokens from the text starting a bit before the first matched term
+// and wraps matched terms with highlight marks, the text is HTML escaped. Text without matches is cut from the start.
+func Snippet(s string, terms []string, words int) string {
+	tokens := Tokens(s)
+	if len(tokens) == 0 {
+		return ""
+	}
+	match := termSet(terms)
+	first := 0
+	for i, t := range tokens {
+		if match[t.Term] {
+			first = i
+			break
+		}
+	}
+	from := first - words/4
+	if from < 0 {
+		from = 0
+	}
+	to := from + words
+	if to > len(tokens) {
+		to = len(tokens)
+	}
+
+	start, end := tokens[from].Start, tokens[to-1].End
+	if from == 0 {
+		start = 0
+	}
+	if to == len(tokens) {
+		end = len(s)
+	}
+	snippet := mark(s, tokens[from:to], match, start, end)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(s) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// termSet makes a lookup set of terms
+func termSet(terms []string) map[string]bool {
+	set := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		set[t] = true
+	}
+	return set
+}
+
+// mark returns s[start:end] with matched tokens wrapped with highlight marks
+// The text is HTML escaped, so the marks are the only markup of the result
+func mark(s string, tokens []Token, match map[string]bool, start, end int) string {
+	b := strings.Builder{}
+	pos := start
+	for _, t := range tokens {
+		if !match[t.Term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(s[pos:t.Start]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(s[t.Start:t.End]))
+		b.WriteString(HighlightEnd)
+		pos = t.End
+	}
+	b.WriteString(html.EscapeString(s[pos:end]))
+	return b.String()
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// AuthorsServer implements GRPC server for Authors of the blog
+type AuthorsServer struct {
+	pb.UnimplementedAuthorsServer
+
+	au repo.AuthorRepo
+}
+
+// NewAuthorsServer returns an AuthorsServer
+func NewAuthorsServer(au repo.AuthorRepo) *AuthorsServer {
+	return &AuthorsServer{
+		au: au,
+	}
+}
+
+// Create an Author
+func (s *AuthorsServer) Create(ctx context.Context, r *pb.CreateAuthorRequest) (*pb.CreateAuthorResponse, error) {
+	a := r.GetAuthor()
+	// need to create an Author since ID and timestamps should be skipped
+	m := &models.Author{
+		DisplayName: a.GetDisplayName(),
+		Bio:         a.GetBio(),
+		AvatarURL:   a.GetAvatarUrl(),
+		Email:       a.GetEmail(),
+	}
+	if err := validateAuthor(*m, models.AuthorFields); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// repo fills ID and timestamps of m
+	if _, err := s.au.AddAuthor(ctx, m); err != nil {
+		log.Println("Got error from repo.AddAuthor", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.CreateAuthorResponse{Author: m.ToPB()}, nil
+}
+
+// Read returns one Author
+func (s *AuthorsServer) Read(ctx context.Context, r *pb.ReadAuthorRequest) (*pb.ReadAuthorResponse, error) {
+	m, err := s.au.GetAuthor(ctx, r.GetId())
+	if err != nil {
+		log.Printf("Error while reading author: %v\n", err)
+		return nil, repoError(err, "id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.ReadAuthorResponse{Author: m.ToPB()}, nil
+}
+
+// Update an Author and returns result with updated Author
+func (s *AuthorsServer) Update(ctx context.Context, r *pb.UpdateAuthorRequest) (*pb.UpdateAuthorResponse, error) {
+	m, err := models.AuthorFromPB(r.GetAuthor())
+	if err != nil {
+		return nil, invalidID("author.id", r.GetAuthor().GetId())
+	}
+	fields, err := authorFields(r.GetUpdateMask())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validateAuthor(*m, fields); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res, err := s.au.UpdateAuthor(ctx, m, fields)
+	if err != nil {
+		log.Printf("Error updating author: %v\n", err)
+		return nil, repoError(err, "author.id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.UpdateAuthorResponse{Author: res.ToPB()}, nil
+}
+
+// authorFields validates update mask and returns Author fields to update
+func authorFields(mask *fieldmaskpb.FieldMask) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return models.AuthorFields, nil
+	}
+	fields := make([]string, 0, len(mask.GetPaths()))
+	for _, p := range mask.GetPaths() {
+		if _, ok := (models.Author{}).Field(p); !ok {
+			return nil, fmt.Errorf("Unknown update mask path: %v", p)
+		}
+		fields = append(fields, p)
+	}
+	return fields, nil
+}
+
+// validateAuthor checks listed fields of the Author
+func validateAuthor(a models.Author, fields []string) error {
+	for _, f := range fields {
+		switch f {
+		case models.FieldDisplayName:
+			if a.DisplayName == "" {
+				return errors.New("Author display name can't be empty")
+			}
+		case models.FieldEmail:
+			if a.Email == "" {
+				continue
+			}
+			if addr, err := mail.ParseAddress(a.Email); err != nil || addr.Address != a.Email {
+				return fmt.Errorf("Invalid author email: %v", a.Email)
+			}
+		case models.FieldAvatarURL:
+			if a.AvatarURL == "" {
+				continue
+			}
+			if u, err := url.Parse(a.AvatarURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("Invalid author avatar URL: %v", a.AvatarURL)
+			}
+		}
+	}
+	return nil
+}
+
+// Delete an Author by ID, Articles of the Author are kept
+func (s *AuthorsServer) Delete(ctx context.Context, r *pb.DeleteAuthorRequest) (*pb.DeleteAuthorResponse, error) {
+	m, err := s.au.DeleteAuthor(ctx, r.GetId())
+	if err != nil {
+		log.Printf("Error deleting author: %v\n", err)
+		return nil, repoError(err, "id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.DeleteAuthorResponse{Id: m.ID.Hex()}, nil
+}
+
+// List returns a page of Authors ordered by ID
+func (s *AuthorsServer) List(ctx context.Context, r *pb.ListAuthorsRequest) (*pb.ListAuthorsResponse, error) {
+	// one extra Author is requested to find out if there's a next page
+	limit, c, err := parsePage(r.GetPageSize(), r.GetPageToken(), nil)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	after := primitive.NilObjectID
+	if c != nil {
+		after = c.ID
+	}
+	list, err := s.au.ListAuthors(ctx, after, limit)
+	if err != nil {
+		log.Printf("Error listing authors: %v\n", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	res := &pb.ListAuthorsResponse{Authors: make([]*pb.Author, 0, len(list))}
+	if limit > 0 && int64(len(list)) == limit {
+		list = list[:len(list)-1]
+		res.NextPageToken = encodePageToken(repo.Cursor{ID: list[len(list)-1].ID})
+	}
+	for _, a := range list {
+		res.Authors = append(res.Authors, a.ToPB())
+	}
+	return res, nil
+}
+
+// checkAuthor makes sure the Author of an Article exists
+// Unknown and missing Authors are invalid arguments, failures to read them are internal errors
+func (s *BlogServer) checkAuthor(ctx context.Context, id string) error {
+	if _, err := s.au.GetAuthor(ctx, id); err != nil {
+		log.Printf("Error reading author of article: %v\n", err)
+		if errors.Is(err, repo.ErrNotFound) || errors.Is(err, repo.ErrInvalidID) {
+			return status.Error(codes.InvalidArgument, fmt.Sprintf("Unknown author: %v", id))
+		}
+		return status.Error(codes.Internal, internalError)
+	}
+	return nil
+}
+
+// withAuthor embeds the Author profile into the Article when requested
+func (s *BlogServer) withAuthor(ctx context.Context, a *pb.Article, include bool) *pb.Article {
+	if include && a.GetAuthorId() != "" {
+		a.Author = s.authorPB(ctx, a.GetAuthorId())
+	}
+	return a
+}
+
+// authorPB returns the Author profile by ID, or nil when it can't be read
+func (s *BlogServer) authorPB(ctx context.Context, id string) *pb.Author {
+	m, err := s.au.GetAuthor(ctx, id)
+	if err != nil {
+		log.Printf("Error reading author %v: %v\n", id, err)
+		return nil
+	}
+	return m.ToPB()
+}
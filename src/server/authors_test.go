@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestAuthors_crud(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Author)
+	s := NewAuthorsServer(repo.NewMapAuthorRepo(m))
+
+	c, err := s.Create(context.Background(), &pb.CreateAuthorRequest{Author: &pb.Author{
+		DisplayName: "Bob",
+		Bio:         "Writes about Go",
+		Email:       "bob@example.com",
+		AvatarUrl:   "https://example.com/bob.png",
+	}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if c.Author.Id == "" || c.Author.CreateTime == nil {
+		t.Fatalf("Expected ID and create time to be set: %v", c.Author)
+	}
+
+	u, err := s.Update(context.Background(), &pb.UpdateAuthorRequest{
+		Author:     &pb.Author{Id: c.Author.Id, Bio: "Writes about Go and Mongo"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"bio"}},
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if u.Author.Bio != "Writes about Go and Mongo" || u.Author.DisplayName != "Bob" {
+		t.Fatalf("Got wrong author: %v", u.Author)
+	}
+
+	rd, err := s.Read(context.Background(), &pb.ReadAuthorRequest{Id: c.Author.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if rd.Author.Bio != u.Author.Bio {
+		t.Fatalf("Got wrong author: %v", rd.Author)
+	}
+
+	if _, err := s.Delete(context.Background(), &pb.DeleteAuthorRequest{Id: c.Author.Id}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(m) != 0 {
+		t.Fatal("Author was not deleted")
+	}
+}
+
+func TestAuthors_invalid(t *testing.T) {
+	s := NewAuthorsServer(repo.NewMapAuthorRepo(make(map[primitive.ObjectID]models.Author)))
+
+	tests := []struct {
+		name   string
+		author *pb.Author
+	}{
+		{"empty display name", &pb.Author{}},
+		{"invalid email", &pb.Author{DisplayName: "Bob", Email: "Bob <bob>"}},
+		{"invalid avatar URL", &pb.Author{DisplayName: "Bob", AvatarUrl: "ftp://example.com/bob.png"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.Create(context.Background(), &pb.CreateAuthorRequest{Author: tt.author})
+			if res != nil {
+				t.Fatalf("Got result: %v", res)
+			}
+			if se, ok := status.FromError(err); !ok {
+				t.Error("Could not initialize status from error")
+			} else if se.Code() != codes.InvalidArgument {
+				t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
+			}
+		})
+	}
+}
+
+func TestAuthors_repo_errors(t *testing.T) {
+	s := NewAuthorsServer(repo.NewMapAuthorRepo(make(map[primitive.ObjectID]models.Author)))
+	ctx := context.Background()
+	missing := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"read missing", func() error {
+			_, err := s.Read(ctx, &pb.ReadAuthorRequest{Id: missing})
+			return err
+		}, codes.NotFound},
+		{"read invalid ID", func() error {
+			_, err := s.Read(ctx, &pb.ReadAuthorRequest{Id: "Bob"})
+			return err
+		}, codes.InvalidArgument},
+		{"update missing", func() error {
+			_, err := s.Update(ctx, &pb.UpdateAuthorRequest{Author: &pb.Author{Id: missing, DisplayName: "Bob"}})
+			return err
+		}, codes.NotFound},
+		{"update invalid ID", func() error {
+			_, err := s.Update(ctx, &pb.UpdateAuthorRequest{Author: &pb.Author{Id: "Bob", DisplayName: "Bob"}})
+			return err
+		}, codes.InvalidArgument},
+		{"delete missing", func() error {
+			_, err := s.Delete(ctx, &pb.DeleteAuthorRequest{Id: missing})
+			return err
+		}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if se, ok := status.FromError(tt.call()); !ok {
+				t.Error("Could not initialize status from error")
+			} else if se.Code() != tt.code {
+				t.Errorf("Error status code is not %v, it's %v", tt.code.String(), se.Code().String())
+			}
+		})
+	}
+}
+
+func TestAuthors_list(t *testing.T) {
+	s := NewAuthorsServer(repo.NewMapAuthorRepo(make(map[primitive.ObjectID]models.Author)))
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		if _, err := s.Create(context.Background(), &pb.CreateAuthorRequest{Author: &pb.Author{DisplayName: name}}); err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+	}
+
+	got := []string{}
+	token := ""
+	for {
+		res, err := s.List(context.Background(), &pb.ListAuthorsRequest{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+		for _, a := range res.Authors {
+			got = append(got, a.DisplayName)
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		token = res.NextPageToken
+	}
+	if len(got) != 3 || got[0] != "Alice" || got[2] != "Carol" {
+		t.Fatalf("Wrong authors: %v", got)
+	}
+}
+
+func TestCreate_unknown_author(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), repo.NewMapAuthorRepo(make(map[primitive.ObjectID]models.Author)))
+
+	res, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: "Bob", Title: "Book1"}})
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.InvalidArgument {
+		t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
+	}
+}
+
+type failingAuthorRepo struct {
+	repo.MapAuthorRepo
+}
+
+func (r *failingAuthorRepo) GetAuthor(_ context.Context, _ string) (*models.Author, error) {
+	return nil, errors.New("connection lost")
+}
+
+func TestCreate_author_repo_error(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), &failingAuthorRepo{})
+
+	_, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if se, ok := status.FromError(err); !ok || se.Code() != codes.Internal {
+		t.Errorf("Expected %v when the author can't be read, got %v", codes.Internal.String(), err)
+	}
+}
+
+func TestArticles_include_author(t *testing.T) {
+	au := make(map[primitive.ObjectID]models.Author)
+	bob := primitive.NewObjectID()
+	au[bob] = models.Author{ID: bob, DisplayName: "Bob"}
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), repo.NewMapAuthorRepo(au))
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: bob.Hex(), Title: "Book1", Status: pb.Article_PUBLISHED}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	rd, err := s.Read(context.Background(), &pb.ReadRequest{Id: c.Article.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if rd.Article.Author != nil {
+		t.Fatalf("Author should be embedded only on request: %v", rd.Article.Author)
+	}
+	rd, err = s.Read(context.Background(), &pb.ReadRequest{Id: c.Article.Id, IncludeAuthor: true})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if rd.Article.Author.GetDisplayName() != "Bob" {
+		t.Fatalf("Expected the author to be embedded, got %v", rd.Article.Author)
+	}
+
+	ts := &testServer{articles: []*pb.Article{}}
+	if err := s.List(&pb.ListRequest{IncludeAuthor: true}, ts); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	if len(ts.articles) != 1 || ts.articles[0].Author.GetDisplayName() != "Bob" {
+		t.Fatalf("Expected the author to be embedded, got %v", ts.articles)
+	}
+}
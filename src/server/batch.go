@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxBatchSize limits the number of items of batch RPCs
+var MaxBatchSize = 500
+
+// BatchCreate creates Articles the way Create does, with a single repo call for the valid ones
+func (s *BlogServer) BatchCreate(ctx context.Context, r *pb.BatchCreateRequest) (*pb.BatchCreateResponse, error) {
+	if err := checkBatchSize(len(r.GetArticles())); err != nil {
+		return nil, err
+	}
+	results := make([]*pb.ArticleResult, len(r.GetArticles()))
+	// valid Articles and their positions in the request
+	ms := make([]*models.Article, 0, len(r.GetArticles()))
+	pos := make([]int, 0, len(r.GetArticles()))
+	for i, a := range r.GetArticles() {
+		m, err := s.newArticle(ctx, a, fmt.Sprintf("articles[%v]", i))
+		if err != nil {
+			results[i] = errorResult(err)
+			continue
+		}
+		ms = append(ms, m)
+		pos = append(pos, i)
+	}
+	errs, err := s.r.AddArticles(ctx, ms)
+	if err != nil {
+		log.Println("Got error from repo.AddArticles", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	for j, m := range ms {
+		if errs[j] != nil {
+			log.Printf("Error adding article of a batch: %v\n", errs[j])
+			results[pos[j]] = errorResult(repoError(errs[j], "id"))
+			continue
+		}
+		results[pos[j]] = &pb.ArticleResult{Result: &pb.ArticleResult_Article{Article: m.ToPB()}}
+	}
+	return &pb.BatchCreateResponse{Results: results}, nil
+}
+
+// BatchGet returns Articles by IDs with a single repo call
+func (s *BlogServer) BatchGet(ctx context.Context, r *pb.BatchGetRequest) (*pb.BatchGetResponse, error) {
+	if err := checkBatchSize(len(r.GetIds())); err != nil {
+		return nil, err
+	}
+	ids := validIDs(r.GetIds())
+	found, err := s.r.GetArticles(ctx, ids)
+	if err != nil {
+		log.Printf("Error while reading a batch: %v\n", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	results := batchResults(r.GetIds(), found)
+	for _, res := range results {
+		if a := res.GetArticle(); a != nil {
+			s.withHTML(a)
+		}
+	}
+	if r.GetIncludeAuthor() {
+		// Articles of the same author share the profile
+		authors := make(map[string]*pb.Author)
+		for _, res := range results {
+			a := res.GetArticle()
+			if a.GetAuthorId() == "" {
+				continue
+			}
+			if _, ok := authors[a.GetAuthorId()]; !ok {
+				authors[a.GetAuthorId()] = s.authorPB(ctx, a.GetAuthorId())
+			}
+			a.Author = authors[a.GetAuthorId()]
+		}
+	}
+	return &pb.BatchGetResponse{Results: results}, nil
+}
+
+// BatchDelete moves Articles by IDs to trash with a single repo call
+func (s *BlogServer) BatchDelete(ctx context.Context, r *pb.BatchDeleteRequest) (*pb.BatchDeleteResponse, error) {
+	if err := checkBatchSize(len(r.GetIds())); err != nil {
+		return nil, err
+	}
+	deleted, err := s.r.DeleteArticles(ctx, validIDs(r.GetIds()))
+	if err != nil {
+		log.Printf("Error deleting a batch: %v\n", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.BatchDeleteResponse{Results: batchResults(r.GetIds(), deleted)}, nil
+}
+
+// checkBatchSize makes sure the batch is not too big
+func checkBatchSize(n int) error {
+	if n > MaxBatchSize {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("Batch can't have more than %v items", MaxBatchSize))
+	}
+	return nil
+}
+
+// validIDs returns canonical forms of valid IDs, invalid ones get errors in batchResults
+func validIDs(ids []string) []string {
+	res := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			res = append(res, oid.Hex())
+		}
+	}
+	return res
+}
+
+// batchResults makes a result per requested ID out of Articles found by the repo
+func batchResults(ids []string, found map[string]models.Article) []*pb.ArticleResult {
+	results := make([]*pb.ArticleResult, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			results = append(results, errorResult(status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid article ID: %v", id))))
+			continue
+		}
+		a, ok := found[oid.Hex()]
+		if !ok {
+			results = append(results, errorResult(status.Error(codes.NotFound, articleNotFound)))
+			continue
+		}
+		results = append(results, &pb.ArticleResult{Result: &pb.ArticleResult_Article{Article: a.ToPB()}})
+	}
+	return results
+}
+
+// errorResult turns a status error into a batch item result
+func errorResult(err error) *pb.ArticleResult {
+	st := status.Convert(err)
+	return &pb.ArticleResult{Result: &pb.ArticleResult_Error{Error: &pb.BatchError{
+		Code:    int32(st.Code()),
+		Message: st.Message(),
+	}}}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBatch_create_get_delete(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+
+	c, err := s.BatchCreate(context.Background(), &pb.BatchCreateRequest{Articles: []*pb.Article{
+		{AuthorId: testAuthor.Hex(), Title: "Book"},
+		{AuthorId: primitive.NewObjectID().Hex(), Title: "Book"},
+		{AuthorId: testAuthor.Hex(), Title: "Book"},
+	}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(c.Results) != 3 || len(m) != 2 {
+		t.Fatalf("Expected two articles to be created, got %v", c.Results)
+	}
+	if c.Results[1].GetError().GetCode() != int32(codes.InvalidArgument) {
+		t.Fatalf("Expected unknown author error, got %v", c.Results[1])
+	}
+	first, third := c.Results[0].GetArticle(), c.Results[2].GetArticle()
+	if first.GetSlug() != "book" || third.GetSlug() != "book-2" {
+		t.Fatalf("Slugs should be unique within the batch: %v, %v", first, third)
+	}
+
+	ids := []string{first.Id, "abc", primitive.NewObjectID().Hex(), third.Id}
+	g, err := s.BatchGet(context.Background(), &pb.BatchGetRequest{Ids: ids})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	codesOf := func(results []*pb.ArticleResult) []codes.Code {
+		res := []codes.Code{}
+		for _, r := range results {
+			res = append(res, codes.Code(r.GetError().GetCode()))
+		}
+		return res
+	}
+	want := []codes.Code{codes.OK, codes.InvalidArgument, codes.NotFound, codes.OK}
+	for i, code := range codesOf(g.Results) {
+		if code != want[i] {
+			t.Fatalf("Wrong results: %v", g.Results)
+		}
+	}
+	if g.Results[3].GetArticle().GetId() != third.Id {
+		t.Fatalf("Results should follow the order of IDs: %v", g.Results)
+	}
+
+	d, err := s.BatchDelete(context.Background(), &pb.BatchDeleteRequest{Ids: ids})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	for i, code := range codesOf(d.Results) {
+		if code != want[i] {
+			t.Fatalf("Wrong results: %v", d.Results)
+		}
+	}
+	for _, a := range m {
+		if !a.IsDeleted() {
+			t.Fatalf("Article was not deleted: %v", a)
+		}
+	}
+	d, err = s.BatchDelete(context.Background(), &pb.BatchDeleteRequest{Ids: []string{first.Id}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if d.Results[0].GetError().GetCode() != int32(codes.NotFound) {
+		t.Fatalf("Articles in trash can't be deleted again: %v", d.Results)
+	}
+}
+
+func TestBatch_too_big(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	res, err := s.BatchGet(context.Background(), &pb.BatchGetRequest{Ids: make([]string, MaxBatchSize+1)})
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.InvalidArgument {
+		t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const articleNotFound = "Article not found"
+
+// CommentsServer implements GRPC server for comments of Articles
+type CommentsServer struct {
+	pb.UnimplementedCommentsServer
+
+	c repo.CommentRepo
+	a repo.ArticleRepo
+}
+
+// NewCommentsServer returns a CommentsServer
+func NewCommentsServer(c repo.CommentRepo, a repo.ArticleRepo) *CommentsServer {
+	return &CommentsServer{
+		c: c,
+		a: a,
+	}
+}
+
+// Create adds a Comment to an Article which is not in trash
+// Replies end up in the thread of their parent Comment
+func (s *CommentsServer) Create(ctx context.Context, r *pb.CreateCommentRequest) (*pb.CreateCommentResponse, error) {
+	c := r.GetComment()
+	if c.GetContent() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Comment content can't be empty")
+	}
+	aid, err := s.articleID(ctx, c.GetArticleId())
+	if err != nil {
+		return nil, err
+	}
+	m := &models.Comment{
+		ArticleID: aid,
+		AuthorID:  c.GetAuthorId(),
+		Content:   c.GetContent(),
+	}
+	if c.GetParentId() != "" {
+		p, err := s.c.GetComment(ctx, c.GetParentId())
+		if err != nil {
+			log.Printf("Error reading parent comment: %v\n", err)
+			return nil, repoError(err, "comment.parent_id")
+		}
+		if p.ArticleID != aid {
+			return nil, status.Error(codes.InvalidArgument, "Parent comment belongs to another article")
+		}
+		m.ReplyTo(*p)
+	}
+	// repo fills ID and create time of m
+	_, err = s.c.AddComment(ctx, m)
+	if err != nil {
+		log.Println("Got error from repo.AddComment", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.CreateCommentResponse{Comment: m.ToPB()}, nil
+}
+
+// articleID parses the ID and makes sure the Article exists and is not in trash
+func (s *CommentsServer) articleID(ctx context.Context, id string) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return oid, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid article ID: %v", id))
+	}
+	if _, err := s.a.GetArticle(ctx, id); err != nil {
+		log.Printf("Error reading article of comments: %v\n", err)
+		return oid, repoError(err, "article_id")
+	}
+	return oid, nil
+}
+
+// List streams Comments of an Article page by page, oldest first
+// The last message of a page carries the token for the next one
+func (s *CommentsServer) List(r *pb.ListCommentsRequest, stream pb.Comments_ListServer) error {
+	aid, err := s.articleID(stream.Context(), r.GetArticleId())
+	if err != nil {
+		return err
+	}
+	q, err := commentsQuery(aid, r)
+	if err != nil {
+		log.Printf("Error parsing list comments request: %v", err)
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.streamComments(q, stream)
+}
+
+// commentsQuery builds repo query out of List comments request
+// one extra Comment is requested to find out if there's a next page
+func commentsQuery(aid primitive.ObjectID, r *pb.ListCommentsRequest) (repo.CommentQuery, error) {
+	q := repo.CommentQuery{ArticleID: aid}
+	if r.GetParentId() != "" {
+		pid, err := primitive.ObjectIDFromHex(r.GetParentId())
+		if err != nil {
+			return q, fmt.Errorf("Invalid parent ID: %v", r.GetParentId())
+		}
+		q.ParentID = pid
+	}
+	limit, c, err := parsePage(r.GetPageSize(), r.GetPageToken(), nil)
+	if err != nil {
+		return q, err
+	}
+	q.Limit = limit
+	if c != nil {
+		q.After = c.ID
+	}
+	return q, nil
+}
+
+// streamComments sends Comments matching the query to the stream
+func (s *CommentsServer) streamComments(q repo.CommentQuery, stream pb.Comments_ListServer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ListTimeout)
+	defer cancel()
+	out := make(chan models.Comment)
+	return streamPage(ctx, page{
+		limit: q.Limit,
+		fill: func(stop <-chan struct{}) error {
+			return s.c.FillComments(ctx, q, out, stop)
+		},
+		recv: func() (interface{}, bool) {
+			v, ok := <-out
+			return v, ok
+		},
+		token: func(v interface{}) string {
+			return encodePageToken(repo.Cursor{ID: v.(models.Comment).ID})
+		},
+		send: func(v interface{}, next string) error {
+			return stream.Send(&pb.ListCommentsResponse{Comment: v.(models.Comment).ToPB(), NextPageToken: next})
+		},
+	})
+}
+
+// Delete a Comment by ID together with all replies to it
+func (s *CommentsServer) Delete(ctx context.Context, r *pb.DeleteCommentRequest) (*pb.DeleteCommentResponse, error) {
+	n, err := s.c.DeleteComment(ctx, r.GetId())
+	if err != nil {
+		log.Printf("Error deleting comment: %v\n", err)
+		return nil, repoError(err, "id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.DeleteCommentResponse{Id: r.GetId(), DeletedCount: n}, nil
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testCommentsServer struct {
+	grpc.ServerStream
+
+	comments []*pb.Comment
+	next     string
+}
+
+func (s *testCommentsServer) Context() context.Context {
+	return context.Background()
+}
+
+func (s *testCommentsServer) Send(m *pb.ListCommentsResponse) error {
+	s.comments = append(s.comments, m.Comment)
+	s.next = m.NextPageToken
+	return nil
+}
+
+// newCommentsTest returns servers sharing an Article repo with one Article in it
+func newCommentsTest(t *testing.T) (*BlogServer, *CommentsServer, map[primitive.ObjectID]models.Comment, string) {
+	r := repo.NewMapRepo(make(map[primitive.ObjectID]models.Article))
+	m := make(map[primitive.ObjectID]models.Comment)
+	b := NewBlogServer(r, testAuthors())
+	c, err := b.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	return b, NewCommentsServer(repo.NewMapCommentRepo(m), r), m, c.Article.Id
+}
+
+func addComment(t *testing.T, s *CommentsServer, articleID, parentID, content string) string {
+	res, err := s.Create(context.Background(), &pb.CreateCommentRequest{Comment: &pb.Comment{
+		ArticleId: articleID,
+		ParentId:  parentID,
+		Content:   content,
+	}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	return res.Comment.Id
+}
+
+func listContents(t *testing.T, s *CommentsServer, r *pb.ListCommentsRequest) []string {
+	ts := &testCommentsServer{}
+	if err := s.List(r, ts); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	contents := []string{}
+	for _, c := range ts.comments {
+		contents = append(contents, c.Content)
+	}
+	return contents
+}
+
+func TestComments_threads(t *testing.T) {
+	_, s, _, aid := newCommentsTest(t)
+
+	c1 := addComment(t, s, aid, "", "c1")
+	c2 := addComment(t, s, aid, "", "c2")
+	r1 := addComment(t, s, aid, c1, "c1.r1")
+	addComment(t, s, aid, r1, "c1.r1.r1")
+	addComment(t, s, aid, c2, "c2.r1")
+
+	all := listContents(t, s, &pb.ListCommentsRequest{ArticleId: aid})
+	if len(all) != 5 || all[0] != "c1" || all[4] != "c2.r1" {
+		t.Fatalf("Comments should be listed oldest first: %v", all)
+	}
+	thread := listContents(t, s, &pb.ListCommentsRequest{ArticleId: aid, ParentId: c1})
+	if len(thread) != 2 || thread[0] != "c1.r1" || thread[1] != "c1.r1.r1" {
+		t.Fatalf("Wrong replies: %v", thread)
+	}
+
+	ts := &testCommentsServer{}
+	if err := s.List(&pb.ListCommentsRequest{ArticleId: aid, PageSize: 3}, ts); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	if len(ts.comments) != 3 || ts.next == "" {
+		t.Fatalf("Expected a full page with next page token, got %v", ts.comments)
+	}
+	if ts.comments[2].ParentId != c1 {
+		t.Fatalf("Wrong parent of a reply: %v", ts.comments[2])
+	}
+	rest := listContents(t, s, &pb.ListCommentsRequest{ArticleId: aid, PageSize: 3, PageToken: ts.next})
+	if len(rest) != 2 || rest[0] != "c1.r1.r1" {
+		t.Fatalf("Wrong second page: %v", rest)
+	}
+
+	d, err := s.Delete(context.Background(), &pb.DeleteCommentRequest{Id: c1})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if d.DeletedCount != 3 {
+		t.Fatalf("Expected the comment deleted with replies, got %v", d.DeletedCount)
+	}
+	if left := listContents(t, s, &pb.ListCommentsRequest{ArticleId: aid}); len(left) != 2 {
+		t.Fatalf("Wrong comments left: %v", left)
+	}
+	_, err = s.Delete(context.Background(), &pb.DeleteCommentRequest{Id: c1})
+	if se, ok := status.FromError(err); !ok || se.Code() != codes.NotFound {
+		t.Fatalf("Expected %v for a deleted comment, got %v", codes.NotFound.String(), err)
+	}
+}
+
+func TestComments_invalid(t *testing.T) {
+	b, s, _, aid := newCommentsTest(t)
+	other, err := b.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book2"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	foreign := addComment(t, s, other.Article.Id, "", "foreign")
+
+	tests := []struct {
+		name    string
+		comment *pb.Comment
+		code    codes.Code
+	}{
+		{"empty content", &pb.Comment{ArticleId: aid}, codes.InvalidArgument},
+		{"invalid article", &pb.Comment{ArticleId: "abc", Content: "c"}, codes.InvalidArgument},
+		{"missing article", &pb.Comment{ArticleId: primitive.NewObjectID().Hex(), Content: "c"}, codes.NotFound},
+		{"missing parent", &pb.Comment{ArticleId: aid, ParentId: primitive.NewObjectID().Hex(), Content: "c"}, codes.NotFound},
+		{"invalid parent ID", &pb.Comment{ArticleId: aid, ParentId: "zzz", Content: "c"}, codes.InvalidArgument},
+		{"parent of another article", &pb.Comment{ArticleId: aid, ParentId: foreign, Content: "c"}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.Create(context.Background(), &pb.CreateCommentRequest{Comment: tt.comment})
+			if res != nil {
+				t.Fatalf("Got result: %v", res)
+			}
+			if se, ok := status.FromError(err); !ok {
+				t.Error("Could not initialize status from error")
+			} else if se.Code() != tt.code {
+				t.Errorf("Error status code is not %v, it's %v", tt.code.String(), se.Code().String())
+			}
+		})
+	}
+}
+
+func TestComments_article_deleted(t *testing.T) {
+	b, s, m, aid := newCommentsTest(t)
+	addComment(t, s, aid, "", "c1")
+
+	if _, err := b.Delete(context.Background(), &pb.DeleteRequest{Id: aid}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	err := s.List(&pb.ListCommentsRequest{ArticleId: aid}, &testCommentsServer{})
+	if se, ok := status.FromError(err); !ok || se.Code() != codes.NotFound {
+		t.Fatalf("Comments of articles in trash should be hidden, got %v", err)
+	}
+	if len(m) != 1 {
+		t.Fatal("Comments should be kept while the article is in trash")
+	}
+
+	defer func(d time.Duration) { TrashRetention = d }(TrashRetention)
+	TrashRetention = -time.Second
+	purgeTrash(context.Background(), b.r, s.c, nil)
+	if len(m) != 0 {
+		t.Fatalf("Comments should be purged with the article: %v", m)
+	}
+}
+
+type failingCommentRepo struct {
+	repo.MapCommentRepo
+}
+
+func (r *failingCommentRepo) DeleteArticleComments(_ context.Context, _ []string) (int64, error) {
+	return 0, errors.New("connection lost")
+}
+
+func TestComments_purge_retried(t *testing.T) {
+	b, s, m, aid := newCommentsTest(t)
+	addComment(t, s, aid, "", "c1")
+	if _, err := b.Delete(context.Background(), &pb.DeleteRequest{Id: aid}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	defer func(d time.Duration) { TrashRetention = d }(TrashRetention)
+	TrashRetention = -time.Second
+	pending := purgeTrash(context.Background(), b.r, &failingCommentRepo{}, nil)
+	if len(pending) != 1 || pending[0] != aid || len(m) != 1 {
+		t.Fatalf("Expected the article pending, got %v", pending)
+	}
+	// the article is gone, its comments are deleted by the retry
+	if pending = purgeTrash(context.Background(), b.r, s.c, pending); len(pending) != 0 || len(m) != 0 {
+		t.Fatalf("Comments should be deleted on retry, pending %v, left %v", pending, m)
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"example.com/grpc/blog/src/repo"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// repoError converts errors of repo calls into gRPC ones with details of the failed document
+// idField names the request field carrying the Article ID, it's reported when the ID is invalid
+// Version conflicts are reported with the current version so the client can reload
+func repoError(err error, idField string) error {
+	var vc *repo.VersionConflictError
+	if errors.As(err, &vc) {
+		return status.Errorf(codes.Aborted, "Article was modified concurrently, current version is %v", vc.Current)
+	}
+	var e *repo.Error
+	if !errors.As(err, &e) {
+		return status.Error(codes.Internal, internalError)
+	}
+	resource := &errdetails.ResourceInfo{ResourceType: e.Kind, ResourceName: e.Key}
+	switch {
+	case errors.Is(e, repo.ErrNotFound):
+		resource.Description = "Not found"
+		return withDetails(status.New(codes.NotFound, fmt.Sprintf("%v not found: %v", e.Kind, e.Key)), resource)
+	case errors.Is(e, repo.ErrConflict):
+		resource.Description = "Already exists"
+		return withDetails(status.New(codes.AlreadyExists, fmt.Sprintf("%v already exists: %v", e.Kind, e.Key)), resource)
+	case errors.Is(e, repo.ErrInvalidID):
+		if e.Kind == repo.KindRevision {
+			idField = "revision_id"
+		}
+		return invalidID(idField, e.Key)
+	}
+	return status.Error(codes.Internal, internalError)
+}
+
+// invalidID reports the request field which doesn't carry a hex ObjectID
+func invalidID(field, id string) error {
+	return withDetails(status.New(codes.InvalidArgument, fmt.Sprintf("Invalid ID: %v", id)), &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+			Field:       field,
+			Description: "Must be a hex ObjectID",
+		}},
+	})
+}
+
+// withDetails attaches details to the status, the bare status is used when they can't be encoded
+func withDetails(st *status.Status, details ...proto.Message) error {
+	ds, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return ds.Err()
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRepoErrors(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	live := models.Article{ID: primitive.NewObjectID(), Title: "t"}
+	m[live.ID] = live
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+	ctx := context.Background()
+	missing := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+		// kind of the missing document, or the invalid field
+		detail string
+	}{
+		{"read missing", func() error {
+			_, err := s.Read(ctx, &pb.ReadRequest{Id: missing})
+			return err
+		}, codes.NotFound, repo.KindArticle},
+		{"read invalid ID", func() error {
+			_, err := s.Read(ctx, &pb.ReadRequest{Id: "nope"})
+			return err
+		}, codes.InvalidArgument, "id"},
+		{"read missing slug", func() error {
+			_, err := s.ReadBySlug(ctx, &pb.ReadBySlugRequest{Slug: "nope"})
+			return err
+		}, codes.NotFound, repo.KindArticle},
+		{"read missing revision", func() error {
+			_, err := s.Read(ctx, &pb.ReadRequest{Id: live.ID.Hex(), RevisionId: missing})
+			return err
+		}, codes.NotFound, repo.KindRevision},
+		{"invalid revision ID", func() error {
+			_, err := s.GetRevision(ctx, &pb.GetRevisionRequest{ArticleId: live.ID.Hex(), RevisionId: "nope"})
+			return err
+		}, codes.InvalidArgument, "revision_id"},
+		{"update invalid ID", func() error {
+			_, err := s.Update(ctx, &pb.UpdateRequest{Article: &pb.Article{Id: "nope"}})
+			return err
+		}, codes.InvalidArgument, "article.id"},
+		{"delete missing", func() error {
+			_, err := s.Delete(ctx, &pb.DeleteRequest{Id: missing})
+			return err
+		}, codes.NotFound, repo.KindArticle},
+		{"undelete live", func() error {
+			_, err := s.Undelete(ctx, &pb.UndeleteRequest{Id: live.ID.Hex()})
+			return err
+		}, codes.NotFound, repo.KindArticle},
+		{"publish missing", func() error {
+			_, err := s.Publish(ctx, &pb.PublishRequest{Id: missing})
+			return err
+		}, codes.NotFound, repo.KindArticle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			se, ok := status.FromError(tt.call())
+			if !ok {
+				t.Fatal("Could not initialize status from error")
+			}
+			if se.Code() != tt.code {
+				t.Fatalf("Error status code is not %v, it's %v", tt.code.String(), se.Code().String())
+			}
+			if len(se.Details()) != 1 {
+				t.Fatalf("Expected one detail, got %v", se.Details())
+			}
+			switch d := se.Details()[0].(type) {
+			case *errdetails.ResourceInfo:
+				if d.ResourceType != tt.detail {
+					t.Errorf("Wrong resource type: %v", d.ResourceType)
+				}
+			case *errdetails.BadRequest:
+				if len(d.FieldViolations) != 1 || d.FieldViolations[0].Field != tt.detail {
+					t.Errorf("Wrong field violations: %v", d.FieldViolations)
+				}
+			default:
+				t.Errorf("Unexpected detail: %v", d)
+			}
+		})
+	}
+}
+
+type mapRepoWithConflict struct {
+	repo.MapArticleRepo
+}
+
+func (r *mapRepoWithConflict) AddArticle(_ context.Context, a *models.Article) (string, error) {
+	return "", &repo.Error{Kind: repo.KindArticle, Key: "ext", Err: repo.ErrConflict}
+}
+
+func TestRepoErrors_conflict(t *testing.T) {
+	s := BlogServer{r: &mapRepoWithConflict{}, au: testAuthors()}
+
+	_, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "t"}})
+	se, ok := status.FromError(err)
+	if !ok || se.Code() != codes.AlreadyExists {
+		t.Fatalf("Expected %v, got %v", codes.AlreadyExists.String(), err)
+	}
+	if len(se.Details()) != 1 {
+		t.Fatalf("Expected one detail, got %v", se.Details())
+	}
+	if d, ok := se.Details()[0].(*errdetails.ResourceInfo); !ok || d.ResourceName != "ext" {
+		t.Errorf("Wrong details: %v", se.Details())
+	}
+}
+
+func TestRepoErrors_other(t *testing.T) {
+	err := repoError(errors.New("connection lost"), "id")
+	if se, ok := status.FromError(err); !ok || se.Code() != codes.Internal || se.Message() != internalError {
+		t.Errorf("Expected internal error, got %v", err)
+	}
+}
@@ -0,0 +1,399 @@
+package server
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+)
+
+// FeedSize is the number of the latest published Articles in a feed
+var FeedSize int64 = 20
+
+// FeedTitle names the blog in feeds
+var FeedTitle = "Blog"
+
+// ArticleURL is the path of an Article page on the blog, formatted with the slug or the ID of Articles without one
+var ArticleURL = "/articles/%v"
+
+// TrustProxy makes feeds take the scheme of links from X-Forwarded-Proto
+// Enable it only behind a proxy which sets the header, clients could forge it otherwise
+var TrustProxy = false
+
+// Feed formats by the file name ending the path
+const (
+	feedRSS  = "feed.rss"
+	feedAtom = "feed.atom"
+)
+
+// FeedHandler serves RSS 2.0 and Atom feeds of the latest published Articles
+// /feed.rss and /feed.atom have every Article, /authors/{id}/feed.rss and /tags/{tag}/feed.rss only matching ones
+func (s *BlogServer) FeedHandler() http.Handler {
+	return http.HandlerFunc(s.serveFeed)
+}
+
+func (s *BlogServer) serveFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	f, format, ok := parseFeedPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), ListTimeout)
+	defer cancel()
+	as, err := collectArticles(ctx, s.r, repo.ArticleQuery{
+		Filter: f,
+		Sort:   repo.SortByPublishTime,
+		Desc:   true,
+		Limit:  FeedSize,
+	})
+	if err != nil {
+		log.Printf("Error reading feed articles: %v\n", err)
+		http.Error(w, internalError, http.StatusInternalServerError)
+		return
+	}
+
+	etag, modified := feedVersion(r.URL.Path, as)
+	modified = s.feeds.modified(r.URL.Path, etag, modified)
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	items := s.feedItems(ctx, as, baseURL(r))
+	var doc interface{}
+	if format == feedAtom {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		doc = atomFeed(baseURL(r)+r.URL.Path, baseURL(r), modified, items)
+	} else {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		doc = rssFeed(baseURL(r), modified, items)
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding feed: %v\n", err)
+		http.Error(w, internalError, http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
+
+// parseFeedPath returns the filter of published Articles and the format the path asks for
+func parseFeedPath(p string) (repo.ArticleFilter, string, bool) {
+	f := repo.ArticleFilter{Statuses: []models.Status{models.StatusPublished}}
+	parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	format := parts[len(parts)-1]
+	if format != feedRSS && format != feedAtom {
+		return f, "", false
+	}
+	switch {
+	case len(parts) == 1:
+	case len(parts) == 3 && parts[0] == "authors" && parts[1] != "":
+		f.AuthorID = parts[1]
+	case len(parts) == 3 && parts[0] == "tags" && parts[1] != "":
+		f.Tags = models.NormalizeTags([]string{parts[1]})
+	default:
+		return f, "", false
+	}
+	return f, format, true
+}
+
+// collectArticles reads every Article matching the query
+func collectArticles(ctx context.Context, r repo.ArticleRepo, q repo.ArticleQuery) ([]models.Article, error) {
+	out := make(chan models.Article)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	as := []models.Article{}
+	go func() {
+		defer close(done)
+		for a := range out {
+			as = append(as, a)
+		}
+	}()
+	err := r.FillArticles(ctx, q, out, stop)
+	<-done
+	// repos wait for the stop signal once everything is read, even when reading failed
+	close(stop)
+	if err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+// feedVersion returns the ETag and the last modification time of a feed
+// ETag changes whenever an Article enters or leaves the feed or gets updated
+func feedVersion(path string, as []models.Article) (string, time.Time) {
+	h := sha1.New()
+	fmt.Fprintln(h, path)
+	var modified time.Time
+	for _, a := range as {
+		fmt.Fprintf(h, "%v:%v\n", a.ID.Hex(), a.Version)
+		for _, t := range []time.Time{a.UpdateTime, a.PublishTime} {
+			if t.After(modified) {
+				modified = t
+			}
+		}
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`, modified
+}
+
+// servedFeed is the version of a feed sent to clients
+type servedFeed struct {
+	etag     string
+	modified time.Time
+}
+
+// feedLog keeps the versions of served feeds, so Last-Modified doesn't go back when Articles leave a feed
+// It starts empty, Last-Modified may still go back once after a restart
+type feedLog struct {
+	mu    sync.Mutex
+	feeds map[string]servedFeed
+}
+
+func newFeedLog() *feedLog {
+	return &feedLog{feeds: make(map[string]servedFeed)}
+}
+
+// modified returns the last modification time of the feed version
+// A changed feed which isn't newer than the served version lost Articles, so it's modified now
+func (l *feedLog) modified(path, etag string, modified time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	last, ok := l.feeds[path]
+	switch {
+	case !ok && modified.IsZero():
+		// empty feeds are not logged, so requests of unknown tags don't grow the log
+		return modified
+	case !ok:
+	case last.etag == etag:
+		return last.modified
+	case !modified.Truncate(time.Second).After(last.modified):
+		// Last-Modified has no fractions of a second, it has to move to the next one at least
+		modified = models.Now()
+		if next := last.modified.Truncate(time.Second).Add(time.Second); modified.Before(next) {
+			modified = next
+		}
+	}
+	l.feeds[path] = servedFeed{etag: etag, modified: modified}
+	return modified
+}
+
+// notModified checks conditional GET headers, If-None-Match takes precedence over If-Modified-Since
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, t := range strings.Split(inm, ",") {
+			t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+			if t == etag || t == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	// Last-Modified has no fractions of a second
+	return !modified.Truncate(time.Second).After(ims)
+}
+
+// baseURL of the blog as the client sees it
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if p := r.Header.Get("X-Forwarded-Proto"); TrustProxy && (p == "http" || p == "https") {
+		scheme = p
+	}
+	return scheme + "://" + r.Host
+}
+
+// feedItem is an Article the way both feed formats need it
+type feedItem struct {
+	article *pb.Article
+	link    string
+	author  string
+	updated time.Time
+}
+
+func (s *BlogServer) feedItems(ctx context.Context, as []models.Article, base string) []feedItem {
+	// Articles of the same author share the name
+	authors := make(map[string]string)
+	items := make([]feedItem, 0, len(as))
+	for _, a := range as {
+		// Articles stored before slugs existed may have none
+		page := a.Slug
+		if page == "" {
+			page = a.ID.Hex()
+		}
+		it := feedItem{
+			article: s.withHTML(a.ToPB()),
+			link:    base + fmt.Sprintf(ArticleURL, page),
+			author:  a.AuthorID,
+			updated: a.UpdateTime,
+		}
+		if a.AuthorID != "" {
+			name, ok := authors[a.AuthorID]
+			if !ok {
+				name = s.authorPB(ctx, a.AuthorID).GetDisplayName()
+				authors[a.AuthorID] = name
+			}
+			if name != "" {
+				it.author = name
+			}
+		}
+		items = append(items, it)
+	}
+	return items
+}
+
+type rss struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	DCNS      string     `xml:"xmlns:dc,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	GUID    string `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+	// RSS author has to be an email, Dublin Core creator is a name
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"content:encoded"`
+}
+
+func rssFeed(base string, modified time.Time, items []feedItem) rss {
+	f := rss{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		DCNS:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       FeedTitle,
+			Link:        base,
+			Description: "Latest articles of " + FeedTitle,
+			Items:       make([]rssItem, 0, len(items)),
+		},
+	}
+	if !modified.IsZero() {
+		f.Channel.LastBuildDate = modified.UTC().Format(time.RFC1123Z)
+	}
+	for _, it := range items {
+		a := it.article
+		f.Channel.Items = append(f.Channel.Items, rssItem{
+			Title:       a.GetTitle(),
+			Link:        it.link,
+			GUID:        it.link,
+			PubDate:     a.GetPublishTime().AsTime().UTC().Format(time.RFC1123Z),
+			Creator:     it.author,
+			Categories:  a.GetTags(),
+			Description: a.GetExcerpt(),
+			Content:     a.GetRenderedHtml(),
+		})
+	}
+	return f
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+}
+
+func atomFeed(self, base string, modified time.Time, items []feedItem) atom {
+	f := atom{
+		ID:      self,
+		Title:   FeedTitle,
+		Updated: modified.UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Rel: "self", Href: self}, {Href: base}},
+		// entries without an author inherit the one of the feed
+		Author:  atomAuthor{Name: FeedTitle},
+		Entries: make([]atomEntry, 0, len(items)),
+	}
+	for _, it := range items {
+		a := it.article
+		e := atomEntry{
+			ID:        it.link,
+			Title:     a.GetTitle(),
+			Link:      atomLink{Rel: "alternate", Href: it.link},
+			Published: a.GetPublishTime().AsTime().UTC().Format(time.RFC3339),
+			Updated:   it.updated.UTC().Format(time.RFC3339),
+			Summary:   atomText{Type: "text", Body: a.GetExcerpt()},
+			Content:   atomText{Type: "html", Body: a.GetRenderedHtml()},
+		}
+		if it.author != "" {
+			e.Author = &atomAuthor{Name: it.author}
+		}
+		for _, t := range a.GetTags() {
+			e.Categories = append(e.Categories, atomCategory{Term: t})
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return f
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newFeedServer returns a server with two published articles and the ID of the author of the older one
+func newFeedServer() (*BlogServer, string) {
+	bob := primitive.NewObjectID()
+	au := map[primitive.ObjectID]models.Author{bob: {ID: bob, DisplayName: "Bob"}}
+	m := make(map[primitive.ObjectID]models.Article)
+	day := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, a := range []models.Article{
+		{Title: "Old", Slug: "old", AuthorID: bob.Hex(), Tags: []string{"go"}, Content: "Old *one*", ContentFormat: "markdown"},
+		{Title: "New", Slug: "new", Tags: []string{"grpc"}, Content: "New one"},
+		{Title: "Draft", Slug: "draft", Status: models.StatusDraft},
+	} {
+		a.ID = primitive.NewObjectID()
+		a.Version = 1
+		if a.Status == "" {
+			a.Status = models.StatusPublished
+			a.PublishTime = day.AddDate(0, 0, i)
+		}
+		a.UpdateTime = day.AddDate(0, 0, i)
+		m[a.ID] = a
+	}
+	return NewBlogServer(repo.NewMapRepo(m), repo.NewMapAuthorRepo(au)), bob.Hex()
+}
+
+func getFeed(s *BlogServer, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "http://blog.example"+path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	s.FeedHandler().ServeHTTP(w, req)
+	return w
+}
+
+func TestFeed_rss(t *testing.T) {
+	s, _ := newFeedServer()
+	w := getFeed(s, "/feed.rss", nil)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %v", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/rss+xml") {
+		t.Fatalf("Unexpected content type %v", ct)
+	}
+	var f rss
+	if err := xml.Unmarshal(w.Body.Bytes(), &f); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+	items := f.Channel.Items
+	if len(items) != 2 || items[0].Title != "New" || items[1].Title != "Old" {
+		t.Fatalf("Expected published articles, latest first: %v", items)
+	}
+	if items[1].Link != "http://blog.example/articles/old" || !strings.Contains(w.Body.String(), "<dc:creator>Bob</dc:creator>") {
+		t.Fatalf("Unexpected item: %+v", items[1])
+	}
+	if !strings.Contains(w.Body.String(), "&lt;em&gt;one&lt;/em&gt;") {
+		t.Fatalf("Expected rendered content in the feed: %v", w.Body.String())
+	}
+	if w.Header().Get("Last-Modified") != "Tue, 02 Mar 2021 00:00:00 GMT" {
+		t.Fatalf("Unexpected Last-Modified %v", w.Header().Get("Last-Modified"))
+	}
+}
+
+func TestFeed_atom_variants(t *testing.T) {
+	s, bob := newFeedServer()
+
+	w := getFeed(s, "/tags/GRPC/feed.atom", nil)
+	var f atom
+	if err := xml.Unmarshal(w.Body.Bytes(), &f); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+	if len(f.Entries) != 1 || f.Entries[0].Title != "New" {
+		t.Fatalf("Expected the article tagged grpc only: %v", f.Entries)
+	}
+	if f.Entries[0].Content.Type != "html" || f.Entries[0].Content.Body != "<p>New one</p>\n" {
+		t.Fatalf("Unexpected content: %v", f.Entries[0].Content)
+	}
+
+	w = getFeed(s, "/authors/"+bob+"/feed.atom", nil)
+	f = atom{}
+	if err := xml.Unmarshal(w.Body.Bytes(), &f); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+	if len(f.Entries) != 1 || f.Entries[0].Author.Name != "Bob" {
+		t.Fatalf("Expected the article of Bob only: %v", f.Entries)
+	}
+}
+
+func TestFeed_not_found(t *testing.T) {
+	s, _ := newFeedServer()
+	for _, p := range []string{"/feed.xml", "/users/1/feed.rss", "/tags//feed.rss", "/a/b/c/feed.rss"} {
+		if w := getFeed(s, p, nil); w.Code != http.StatusNotFound {
+			t.Fatalf("Expected 404 for %v, got %v", p, w.Code)
+		}
+	}
+}
+
+func TestFeed_conditional_get(t *testing.T) {
+	s, _ := newFeedServer()
+	w := getFeed(s, "/feed.rss", nil)
+	etag := w.Header().Get("ETag")
+	modified := w.Header().Get("Last-Modified")
+	if etag == "" {
+		t.Fatal("Expected ETag")
+	}
+
+	if w := getFeed(s, "/feed.rss", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("Expected 304 for matching ETag, got %v", w.Code)
+	}
+	if w := getFeed(s, "/feed.atom", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusOK {
+		t.Fatalf("Expected the ETag to differ between formats, got %v", w.Code)
+	}
+	if w := getFeed(s, "/feed.rss", http.Header{"If-Modified-Since": {modified}}); w.Code != http.StatusNotModified {
+		t.Fatalf("Expected 304 when not modified since, got %v", w.Code)
+	}
+	if w := getFeed(s, "/feed.rss", http.Header{"If-Modified-Since": {"Mon, 01 Mar 2021 00:00:00 GMT"}}); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 when modified since, got %v", w.Code)
+	}
+}
+
+func TestFeed_last_modified_after_removal(t *testing.T) {
+	s, _ := newFeedServer()
+	w := getFeed(s, "/feed.rss", nil)
+	modified, _ := http.ParseTime(w.Header().Get("Last-Modified"))
+
+	as, err := s.r.GetArticlesByKey(context.Background(), repo.KeySlug, []string{"new"})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := s.r.SetStatus(context.Background(), as["new"].ID.Hex(), models.StatusDraft, time.Time{}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	w = getFeed(s, "/feed.rss", http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the feed to be modified, got %v", w.Code)
+	}
+	if m, _ := http.ParseTime(w.Header().Get("Last-Modified")); !m.After(modified) {
+		t.Fatalf("Last-Modified went back from %v to %v", modified, m)
+	}
+}
+
+func TestFeed_links(t *testing.T) {
+	id := primitive.NewObjectID()
+	m := map[primitive.ObjectID]models.Article{id: {
+		ID: id, Title: "Legacy", Status: models.StatusPublished, PublishTime: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+	forwarded := http.Header{"X-Forwarded-Proto": {"https"}}
+
+	var f rss
+	if err := xml.Unmarshal(getFeed(s, "/feed.rss", forwarded).Body.Bytes(), &f); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+	if link := "http://blog.example/articles/" + id.Hex(); f.Channel.Items[0].Link != link || f.Channel.Items[0].GUID != link {
+		t.Fatalf("Expected the ID in the link without a slug and X-Forwarded-Proto to be ignored: %+v", f.Channel.Items[0])
+	}
+
+	TrustProxy = true
+	defer func() { TrustProxy = false }()
+	f = rss{}
+	if err := xml.Unmarshal(getFeed(s, "/feed.rss", forwarded).Body.Bytes(), &f); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+	if f.Channel.Link != "https://blog.example" {
+		t.Fatalf("Expected the forwarded scheme behind a trusted proxy: %v", f.Channel.Link)
+	}
+}
+
+type failingFillRepo struct {
+	repo.MapArticleRepo
+
+	stopped chan struct{}
+}
+
+func (r *failingFillRepo) FillArticles(_ context.Context, _ repo.ArticleQuery, out chan<- models.Article, stop <-chan struct{}) error {
+	close(out)
+	go func() {
+		<-stop
+		close(r.stopped)
+	}()
+	return errors.New("connection lost")
+}
+
+func TestFeed_fill_error(t *testing.T) {
+	r := &failingFillRepo{stopped: make(chan struct{})}
+	if _, err := collectArticles(context.Background(), r, repo.ArticleQuery{}); err == nil {
+		t.Fatal("Expected the error of the repo")
+	}
+	select {
+	case <-r.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Repo didn't get the stop signal after failing")
+	}
+}
@@ -0,0 +1,413 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// GatewayPrefix is the path of Articles in the REST gateway
+const GatewayPrefix = "/v1/articles"
+
+// ndjson is the media type of List streamed as one JSON message per line
+const ndjson = "application/x-ndjson"
+
+// MaxBodySize limits request bodies of the REST gateway
+var MaxBodySize int64 = 1 << 20
+
+// GatewayHandler serves the Blog service as JSON over HTTP
+//
+//	GET    /v1/articles       List, a JSON array of a page or NDJSON stream when Accept asks for it
+//	POST   /v1/articles       Create
+//	GET    /v1/articles/{id}  Read
+//	PATCH  /v1/articles/{id}  Update, updatable fields present in the body are updated unless update_mask is given
+//	DELETE /v1/articles/{id}  Delete
+func (s *BlogServer) GatewayHandler() http.Handler {
+	return http.HandlerFunc(s.serveGateway)
+}
+
+func (s *BlogServer) serveGateway(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, GatewayPrefix)
+	if rest == r.URL.Path || rest != "" && (rest[0] != '/' || strings.Contains(rest[1:], "/") || rest == "/") {
+		writeHTTPError(w, status.Error(codes.NotFound, "Unknown path"))
+		return
+	}
+	id := strings.TrimPrefix(rest, "/")
+	var err error
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		err = s.gatewayList(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		err = s.gatewayCreate(w, r)
+	case id != "" && r.Method == http.MethodGet:
+		err = s.gatewayRead(w, r, id)
+	case id != "" && r.Method == http.MethodPatch:
+		err = s.gatewayUpdate(w, r, id)
+	case id != "" && r.Method == http.MethodDelete:
+		err = s.gatewayDelete(w, r, id)
+	default:
+		allow := "GET, POST"
+		if id != "" {
+			allow = "GET, PATCH, DELETE"
+		}
+		w.Header().Set("Allow", allow)
+		writeStatus(w, http.StatusMethodNotAllowed, status.Newf(codes.Unimplemented, "Method %v is not allowed", r.Method))
+		return
+	}
+	if err != nil {
+		writeHTTPError(w, err)
+	}
+}
+
+func (s *BlogServer) gatewayCreate(w http.ResponseWriter, r *http.Request) error {
+	a := &pb.Article{}
+	if _, err := readBody(w, r, a); err != nil {
+		return err
+	}
+	res, err := s.Create(r.Context(), &pb.CreateRequest{Article: a})
+	if err := rpcError(err); err != nil {
+		return err
+	}
+	w.Header().Set("Location", GatewayPrefix+"/"+res.GetArticle().GetId())
+	return writeJSON(w, http.StatusCreated, res.GetArticle())
+}
+
+func (s *BlogServer) gatewayRead(w http.ResponseWriter, r *http.Request, id string) error {
+	q := r.URL.Query()
+	include, err := boolParam(q, "include_author")
+	if err != nil {
+		return err
+	}
+	res, err := s.Read(r.Context(), &pb.ReadRequest{Id: id, RevisionId: q.Get("revision_id"), IncludeAuthor: include})
+	if err := rpcError(err); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, res.GetArticle())
+}
+
+func (s *BlogServer) gatewayUpdate(w http.ResponseWriter, r *http.Request, id string) error {
+	q := r.URL.Query()
+	version, err := intParam(q, "expected_version")
+	if err != nil {
+		return err
+	}
+	a := &pb.Article{}
+	present, err := readBody(w, r, a)
+	if err != nil {
+		return err
+	}
+	a.Id = id
+	// output only fields such as version or update_time are ignored, so a read Article can be sent back
+	mask := &fieldmaskpb.FieldMask{}
+	for _, f := range present {
+		for _, u := range models.AllFields {
+			if f == u {
+				mask.Paths = append(mask.Paths, f)
+			}
+		}
+	}
+	if m := q.Get("update_mask"); m != "" {
+		mask.Paths = strings.Split(m, ",")
+	}
+	if len(mask.Paths) == 0 {
+		return status.Error(codes.InvalidArgument, "Nothing to update")
+	}
+	res, err := s.Update(r.Context(), &pb.UpdateRequest{Article: a, UpdateMask: mask, ExpectedVersion: version})
+	if err := rpcError(err); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, res.GetArticle())
+}
+
+func (s *BlogServer) gatewayDelete(w http.ResponseWriter, r *http.Request, id string) error {
+	version, err := intParam(r.URL.Query(), "expected_version")
+	if err != nil {
+		return err
+	}
+	res, err := s.Delete(r.Context(), &pb.DeleteRequest{Id: id, ExpectedVersion: version})
+	if err := rpcError(err); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, res)
+}
+
+func (s *BlogServer) gatewayList(w http.ResponseWriter, r *http.Request) error {
+	req, err := listParams(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	q, err := listQuery(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if strings.Contains(r.Header.Get("Accept"), ndjson) {
+		ns := &ndjsonSender{w: w}
+		err := s.streamArticles(q, req.GetIncludeAuthor(), ns)
+		if err != nil && ns.sent {
+			// the status is sent already, so the error becomes the last line
+			ns.sendError(err)
+			return nil
+		}
+		if err == nil && !ns.sent {
+			w.Header().Set("Content-Type", ndjson)
+		}
+		return err
+	}
+	ps := &pageSender{}
+	if err := s.streamArticles(q, req.GetIncludeAuthor(), ps); err != nil {
+		return err
+	}
+	items := make([]json.RawMessage, 0, len(ps.page))
+	next := ""
+	for _, m := range ps.page {
+		b, err := protojson.Marshal(m.GetArticle())
+		if err != nil {
+			log.Printf("Error encoding article: %v\n", err)
+			return status.Error(codes.Internal, internalError)
+		}
+		items = append(items, b)
+		next = m.GetNextPageToken()
+	}
+	if next != "" {
+		nq := r.URL.Query()
+		nq.Set("page_token", next)
+		w.Header().Set("X-Next-Page-Token", next)
+		w.Header().Set("Link", fmt.Sprintf(`<%v?%v>; rel="next"`, GatewayPrefix, nq.Encode()))
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		log.Printf("Error encoding page: %v\n", err)
+		return status.Error(codes.Internal, internalError)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+	return nil
+}
+
+// pageSender keeps a page of List in memory
+type pageSender struct {
+	page []*pb.ListResponse
+}
+
+func (p *pageSender) Send(r *pb.ListResponse) error {
+	p.page = append(p.page, r)
+	return nil
+}
+
+// ndjsonSender writes every List message as a line as soon as it's sent
+type ndjsonSender struct {
+	w    http.ResponseWriter
+	sent bool
+}
+
+func (n *ndjsonSender) Send(r *pb.ListResponse) error {
+	b, err := protojson.Marshal(r)
+	if err != nil {
+		return err
+	}
+	n.write(b)
+	return nil
+}
+
+func (n *ndjsonSender) sendError(err error) {
+	b, err := protojson.Marshal(status.Convert(err).Proto())
+	if err != nil {
+		log.Printf("Error encoding status: %v\n", err)
+		return
+	}
+	n.write([]byte(`{"error":` + string(b) + "}"))
+}
+
+func (n *ndjsonSender) write(b []byte) {
+	if !n.sent {
+		n.w.Header().Set("Content-Type", ndjson)
+		n.sent = true
+	}
+	n.w.Write(append(b, '\n'))
+	if f, ok := n.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// listParams builds List request out of query parameters named after the request and filter fields
+// tag and status may be repeated, sort is the name of a Sort field
+func listParams(q url.Values) (*pb.ListRequest, error) {
+	size, err := intParam(q, "page_size")
+	if err != nil {
+		return nil, err
+	}
+	desc, err := boolParam(q, "desc")
+	if err != nil {
+		return nil, err
+	}
+	include, err := boolParam(q, "include_author")
+	if err != nil {
+		return nil, err
+	}
+	summary, err := boolParam(q, "summary_only")
+	if err != nil {
+		return nil, err
+	}
+	r := &pb.ListRequest{
+		PageSize:  int32(size),
+		PageToken: q.Get("page_token"),
+		Filter: &pb.ArticleFilter{
+			AuthorId:        q.Get("author_id"),
+			TitlePrefix:     q.Get("title_prefix"),
+			TitleContains:   q.Get("title_contains"),
+			ContentContains: q.Get("content_contains"),
+			Tags:            q["tag"],
+		},
+		Sort:          &pb.Sort{Descending: desc},
+		IncludeAuthor: include,
+		SummaryOnly:   summary,
+	}
+	for _, st := range q["status"] {
+		v, ok := pb.Article_Status_value[strings.ToUpper(st)]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown status: %v", st)
+		}
+		r.Filter.Statuses = append(r.Filter.Statuses, pb.Article_Status(v))
+	}
+	if sf := q.Get("sort"); sf != "" {
+		v, ok := pb.Sort_Field_value[strings.ToUpper(sf)]
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, invalidSortField)
+		}
+		r.Sort.Field = pb.Sort_Field(v)
+	}
+	return r, nil
+}
+
+func intParam(q url.Values, name string) (int64, error) {
+	if q.Get(name) == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(q.Get(name), 10, 64)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "Parameter %v must be an integer", name)
+	}
+	return v, nil
+}
+
+func boolParam(q url.Values, name string) (bool, error) {
+	if q.Get(name) == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(q.Get(name))
+	if err != nil {
+		return false, status.Errorf(codes.InvalidArgument, "Parameter %v must be a boolean", name)
+	}
+	return v, nil
+}
+
+// readBody decodes the JSON body into the message
+// returns proto names of the top level fields present in the body
+func readBody(w http.ResponseWriter, r *http.Request, m proto.Message) ([]string, error) {
+	b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Request body is too large or can't be read")
+	}
+	if err := protojson.Unmarshal(b, m); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid request body: %v", err)
+	}
+	keys := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid request body: %v", err)
+	}
+	fields := m.ProtoReflect().Descriptor().Fields()
+	present := make([]string, 0, len(keys))
+	for k := range keys {
+		// protojson accepts both JSON and proto names
+		fd := fields.ByJSONName(k)
+		if fd == nil {
+			fd = fields.ByName(protoreflect.Name(k))
+		}
+		// ID comes from the path
+		if fd == nil || fd.Name() == "id" {
+			continue
+		}
+		present = append(present, string(fd.Name()))
+	}
+	return present, nil
+}
+
+// rpcError drops the errors with OK code some of the methods return along with the result
+func rpcError(err error) error {
+	if status.Code(err) == codes.OK {
+		return nil
+	}
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, code int, m proto.Message) error {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		log.Printf("Error encoding response: %v\n", err)
+		return status.Error(codes.Internal, internalError)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+	return nil
+}
+
+// writeHTTPError writes gRPC status of the error as JSON with the matching HTTP status
+func writeHTTPError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeStatus(w, httpStatus(st.Code()), st)
+}
+
+func writeStatus(w http.ResponseWriter, code int, st *status.Status) {
+	b, err := protojson.Marshal(st.Proto())
+	if err != nil {
+		log.Printf("Error encoding status: %v\n", err)
+		http.Error(w, internalError, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+// httpStatuses maps gRPC codes onto HTTP statuses the way google.rpc.Code documents them
+var httpStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+func httpStatus(c codes.Code) int {
+	if s, ok := httpStatuses[c]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func newGatewayServer() *BlogServer {
+	return NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+}
+
+func callGateway(s *BlogServer, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	s.GatewayHandler().ServeHTTP(w, req)
+	return w
+}
+
+func gatewayArticle(t *testing.T, w *httptest.ResponseRecorder) *pb.Article {
+	t.Helper()
+	a := &pb.Article{}
+	if err := protojson.Unmarshal(w.Body.Bytes(), a); err != nil {
+		t.Fatalf("Response is not an article: %v %v", err, w.Body.String())
+	}
+	return a
+}
+
+func TestGateway_crud(t *testing.T) {
+	s := newGatewayServer()
+
+	w := callGateway(s, http.MethodPost, "/v1/articles", `{"author_id": "`+testAuthor.Hex()+`", "title": "Book1", "content": "Once", "tags": ["go"]}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %v: %v", w.Code, w.Body.String())
+	}
+	created := gatewayArticle(t, w)
+	if w.Header().Get("Location") != "/v1/articles/"+created.Id {
+		t.Fatalf("Unexpected location %v", w.Header().Get("Location"))
+	}
+
+	w = callGateway(s, http.MethodGet, "/v1/articles/"+created.Id, "", nil)
+	if w.Code != http.StatusOK || gatewayArticle(t, w).Title != "Book1" {
+		t.Fatalf("Unexpected read: %v %v", w.Code, w.Body.String())
+	}
+
+	w = callGateway(s, http.MethodPatch, "/v1/articles/"+created.Id, `{"title": "Book2"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %v: %v", w.Code, w.Body.String())
+	}
+	updated := gatewayArticle(t, w)
+	if updated.Title != "Book2" || updated.Content != "Once" || len(updated.Tags) != 1 {
+		t.Fatalf("Expected only the title to be updated: %v", updated)
+	}
+
+	read := callGateway(s, http.MethodGet, "/v1/articles/"+created.Id, "", nil).Body.String()
+	w = callGateway(s, http.MethodPatch, "/v1/articles/"+created.Id, read, nil)
+	if w.Code != http.StatusOK || gatewayArticle(t, w).Title != "Book2" {
+		t.Fatalf("Expected a read article to be accepted back: %v %v", w.Code, w.Body.String())
+	}
+
+	w = callGateway(s, http.MethodPatch, "/v1/articles/"+created.Id+"?expected_version=1", `{"content": "Twice"}`, nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected 409 for a stale version, got %v: %v", w.Code, w.Body.String())
+	}
+
+	w = callGateway(s, http.MethodDelete, "/v1/articles/"+created.Id, "", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), created.Id) {
+		t.Fatalf("Unexpected delete: %v %v", w.Code, w.Body.String())
+	}
+}
+
+func TestGateway_errors(t *testing.T) {
+	s := newGatewayServer()
+	tests := []struct {
+		method, target, body string
+		code                 int
+	}{
+		{http.MethodPost, "/v1/articles", `{"title": 1}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/articles", `{"unknown": "x"}`, http.StatusBadRequest},
+		{http.MethodPost, "/v1/articles", `{"status": "ARCHIVED"}`, http.StatusBadRequest},
+		{http.MethodGet, "/v1/articles?page_size=x", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/articles?sort=body", "", http.StatusBadRequest},
+		{http.MethodGet, "/v1/articles/1/2", "", http.StatusNotFound},
+		{http.MethodGet, "/v1/articlesx", "", http.StatusNotFound},
+		{http.MethodPut, "/v1/articles/1", "{}", http.StatusMethodNotAllowed},
+		{http.MethodPatch, "/v1/articles/1", `{"id": "1"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := callGateway(s, tt.method, tt.target, tt.body, nil)
+		if w.Code != tt.code {
+			t.Fatalf("Expected %v for %v %v, got %v: %v", tt.code, tt.method, tt.target, w.Code, w.Body.String())
+		}
+		var st struct {
+			Code    codes.Code
+			Message string
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &st); err != nil || st.Message == "" {
+			t.Fatalf("Expected status in the body: %v", w.Body.String())
+		}
+	}
+}
+
+func TestGateway_status_mapping(t *testing.T) {
+	for c, want := range map[codes.Code]int{
+		codes.NotFound:          http.StatusNotFound,
+		codes.AlreadyExists:     http.StatusConflict,
+		codes.DeadlineExceeded:  http.StatusGatewayTimeout,
+		codes.ResourceExhausted: http.StatusTooManyRequests,
+		codes.Code(100):         http.StatusInternalServerError,
+	} {
+		if got := httpStatus(c); got != want {
+			t.Fatalf("Expected %v for %v, got %v", want, c, got)
+		}
+	}
+}
+
+func TestGateway_list(t *testing.T) {
+	s := newGatewayServer()
+	for _, title := range []string{"Book1", "Book2", "Book3"} {
+		w := callGateway(s, http.MethodPost, "/v1/articles", `{"author_id": "`+testAuthor.Hex()+`", "title": "`+title+`", "status": "PUBLISHED"}`, nil)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %v: %v", w.Code, w.Body.String())
+		}
+	}
+
+	w := callGateway(s, http.MethodGet, "/v1/articles?page_size=2&sort=title", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %v: %v", w.Code, w.Body.String())
+	}
+	var page []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Expected a JSON array: %v", w.Body.String())
+	}
+	if len(page) != 2 || page[0]["title"] != "Book1" {
+		t.Fatalf("Unexpected first page: %v", page)
+	}
+	next := w.Header().Get("X-Next-Page-Token")
+	if next == "" || !strings.Contains(w.Header().Get("Link"), `rel="next"`) {
+		t.Fatalf("Expected next page headers: %v", w.Header())
+	}
+
+	w = callGateway(s, http.MethodGet, "/v1/articles?page_size=2&sort=title&page_token="+next, "", nil)
+	page = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || len(page) != 1 || page[0]["title"] != "Book3" {
+		t.Fatalf("Unexpected last page: %v", w.Body.String())
+	}
+	if w.Header().Get("X-Next-Page-Token") != "" {
+		t.Fatal("Expected no next page token on the last page")
+	}
+
+	w = callGateway(s, http.MethodGet, "/v1/articles?sort=title&desc=true", "", http.Header{"Accept": {ndjson}})
+	if w.Header().Get("Content-Type") != ndjson {
+		t.Fatalf("Expected NDJSON, got %v", w.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %v", lines)
+	}
+	res := &pb.ListResponse{}
+	if err := protojson.Unmarshal([]byte(lines[0]), res); err != nil || res.Article.Title != "Book3" {
+		t.Fatalf("Unexpected first line: %v", lines[0])
+	}
+}
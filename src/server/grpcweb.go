@@ -0,0 +1,201 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// AllowedOrigins lists origins browsers may call gRPC-Web from with credentials
+// any origin is allowed when empty, but browsers don't send cookies or auth to it then
+var AllowedOrigins []string
+
+// gRPC-Web content types, text ones carry base64 encoded frames
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+)
+
+// grpcWebTrailerFlag marks the frame which carries trailers in the body
+const grpcWebTrailerFlag = 0x80
+
+// GRPCWebHandler serves gRPC-Web, so browsers can call the services without a proxy
+// gRPC-Web requests are translated into gRPC ones served by gs, including CORS preflight
+// Native gRPC clients are expected to call gs on its own listener
+func GRPCWebHandler(gs *grpc.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			servePreflight(w, r)
+			return
+		}
+		if !isGRPCWeb(r) {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		serveGRPCWeb(gs, w, r)
+	})
+}
+
+func isGRPCWeb(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), grpcWebContentType)
+}
+
+// allowOrigin sets CORS headers of an allowed origin, returns false when the origin is not allowed
+func allowOrigin(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(AllowedOrigins) == 0 {
+		// credentials of a reflected origin would let any page act as its visitors
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return true
+	}
+	allowed := false
+	for _, o := range AllowedOrigins {
+		if o == origin {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Add("Vary", "Origin")
+	return true
+}
+
+func servePreflight(w http.ResponseWriter, r *http.Request) {
+	if !allowOrigin(w, r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+	// clients send their own metadata, so every requested header is allowed
+	if h := r.Header.Get("Access-Control-Request-Headers"); h != "" {
+		w.Header().Set("Access-Control-Allow-Headers", h)
+	}
+	w.Header().Set("Access-Control-Max-Age", "600")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func serveGRPCWeb(gs *grpc.Server, w http.ResponseWriter, r *http.Request) {
+	if !allowOrigin(w, r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	ct := r.Header.Get("Content-Type")
+	text := strings.HasPrefix(ct, grpcWebTextContentType)
+	// the rest of the content type names the codec, like +proto
+	subtype := strings.TrimPrefix(strings.TrimPrefix(ct, grpcWebTextContentType), grpcWebContentType)
+
+	gr := r.Clone(r.Context())
+	gr.ProtoMajor, gr.ProtoMinor, gr.Proto = 2, 0, "HTTP/2.0"
+	gr.Header.Set("Content-Type", "application/grpc"+subtype)
+	gr.Header.Del("Content-Length")
+	gr.ContentLength = -1
+	if text {
+		gr.Body = struct {
+			io.Reader
+			io.Closer
+		}{base64.NewDecoder(base64.StdEncoding, r.Body), r.Body}
+	}
+
+	ww := &grpcWebWriter{w: w, header: make(http.Header), text: text, contentType: ct}
+	gs.ServeHTTP(ww, gr)
+	ww.finish()
+}
+
+// grpcWebWriter turns gRPC responses into gRPC-Web ones, trailers are sent as the last frame of the body
+type grpcWebWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	text        bool
+	contentType string
+	wroteHeader bool
+}
+
+func (g *grpcWebWriter) Header() http.Header {
+	return g.header
+}
+
+func (g *grpcWebWriter) WriteHeader(code int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+	h := g.w.Header()
+	exposed := []string{}
+	for k, vv := range g.header {
+		if k == "Trailer" || k == "Content-Type" || strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		h[k] = vv
+		exposed = append(exposed, k)
+	}
+	h.Set("Content-Type", g.contentType)
+	// browsers hide headers which are not exposed, trailers are in the body
+	sort.Strings(exposed)
+	h.Set("Access-Control-Expose-Headers", strings.Join(append(exposed, "Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"), ", "))
+	// the header map is reused for trailers
+	g.header = make(http.Header)
+	g.w.WriteHeader(code)
+}
+
+func (g *grpcWebWriter) Write(b []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.text {
+		// every write is padded on its own, clients decode the body by 4 characters
+		if _, err := io.WriteString(g.w, base64.StdEncoding.EncodeToString(b)); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return g.w.Write(b)
+}
+
+func (g *grpcWebWriter) Flush() {
+	if f, ok := g.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes trailers set after the header as the trailer frame
+func (g *grpcWebWriter) finish() {
+	if !g.wroteHeader {
+		// trailers-only response, the status is set before anything is written
+		trailers := g.header
+		g.header = make(http.Header)
+		g.WriteHeader(http.StatusOK)
+		g.header = trailers
+	}
+	var b bytes.Buffer
+	keys := make([]string, 0, len(g.header))
+	for k := range g.header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "Trailer" || k == "Content-Type" {
+			continue
+		}
+		name := strings.ToLower(strings.TrimPrefix(k, http.TrailerPrefix))
+		for _, v := range g.header[k] {
+			b.WriteString(name + ": " + v + "\r\n")
+		}
+	}
+	frame := make([]byte, 5, 5+b.Len())
+	frame[0] = grpcWebTrailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(b.Len()))
+	g.Write(append(frame, b.Bytes()...))
+	g.Flush()
+}
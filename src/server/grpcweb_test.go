@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func newGRPCWebServer(t *testing.T) *httptest.Server {
+	gs := grpc.NewServer()
+	pb.RegisterBlogServer(gs, NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors()))
+	hs := httptest.NewServer(GRPCWebHandler(gs))
+	t.Cleanup(hs.Close)
+	return hs
+}
+
+// grpcWebCall sends the message as a gRPC-Web request and returns data frames and trailers of the response
+func grpcWebCall(t *testing.T, hs *httptest.Server, method string, m proto.Message, text bool) (*http.Response, [][]byte, map[string]string) {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	frame := make([]byte, 5, 5+len(b))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(b)))
+	body := append(frame, b...)
+	ct := "application/grpc-web+proto"
+	if text {
+		body = []byte(base64.StdEncoding.EncodeToString(body))
+		ct = "application/grpc-web-text+proto"
+	}
+	req, _ := http.NewRequest(http.MethodPost, hs.URL+"/blog.Blog/"+method, bytes.NewReader(body))
+	req.Header.Set("Content-Type", ct)
+	req.Header.Set("Origin", "http://admin.example")
+	res, err := hs.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	defer res.Body.Close()
+	out, _ := ioutil.ReadAll(res.Body)
+	if text {
+		// every chunk of the body is padded on its own
+		var dec []byte
+		for i := 0; i+4 <= len(out); i += 4 {
+			d, err := base64.StdEncoding.DecodeString(string(out[i : i+4]))
+			if err != nil {
+				t.Fatalf("Body is not base64: %v", err)
+			}
+			dec = append(dec, d...)
+		}
+		out = dec
+	}
+	frames := [][]byte{}
+	trailers := map[string]string{}
+	for len(out) >= 5 {
+		n := binary.BigEndian.Uint32(out[1:5])
+		data := out[5 : 5+n]
+		if out[0]&grpcWebTrailerFlag != 0 {
+			for _, l := range strings.Split(strings.TrimSpace(string(data)), "\r\n") {
+				kv := strings.SplitN(l, ": ", 2)
+				trailers[kv[0]] = kv[1]
+			}
+		} else {
+			frames = append(frames, data)
+		}
+		out = out[5+n:]
+	}
+	return res, frames, trailers
+}
+
+func TestGRPCWeb_unary(t *testing.T) {
+	hs := newGRPCWebServer(t)
+
+	res, frames, trailers := grpcWebCall(t, hs, "Create", &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}}, false)
+	if res.Header.Get("Content-Type") != "application/grpc-web+proto" {
+		t.Fatalf("Unexpected content type %v", res.Header.Get("Content-Type"))
+	}
+	if res.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("Expected CORS headers: %v", res.Header)
+	}
+	if trailers["grpc-status"] != "0" || len(frames) != 1 {
+		t.Fatalf("Expected one message and OK status: %v %v", frames, trailers)
+	}
+	cr := &pb.CreateResponse{}
+	if err := proto.Unmarshal(frames[0], cr); err != nil || cr.Article.Title != "Book1" {
+		t.Fatalf("Unexpected response: %v %v", cr, err)
+	}
+}
+
+func TestGRPCWeb_error(t *testing.T) {
+	hs := newGRPCWebServer(t)
+
+	_, frames, trailers := grpcWebCall(t, hs, "Create", &pb.CreateRequest{Article: &pb.Article{Status: pb.Article_ARCHIVED}}, false)
+	if len(frames) != 0 || trailers["grpc-status"] != "3" || trailers["grpc-message"] == "" {
+		t.Fatalf("Expected InvalidArgument in trailers: %v %v", frames, trailers)
+	}
+}
+
+func TestGRPCWeb_text_server_streaming(t *testing.T) {
+	hs := newGRPCWebServer(t)
+	for _, title := range []string{"Book1", "Book2"} {
+		if _, _, tr := grpcWebCall(t, hs, "Create", &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: title, Status: pb.Article_PUBLISHED}}, true); tr["grpc-status"] != "0" {
+			t.Fatalf("Got error creating: %v", tr)
+		}
+	}
+
+	res, frames, trailers := grpcWebCall(t, hs, "List", &pb.ListRequest{}, true)
+	if res.Header.Get("Content-Type") != "application/grpc-web-text+proto" {
+		t.Fatalf("Unexpected content type %v", res.Header.Get("Content-Type"))
+	}
+	if trailers["grpc-status"] != "0" || len(frames) != 2 {
+		t.Fatalf("Expected two messages and OK status: %v %v", len(frames), trailers)
+	}
+	lr := &pb.ListResponse{}
+	if err := proto.Unmarshal(frames[1], lr); err != nil || lr.Article.Title != "Book2" {
+		t.Fatalf("Unexpected message: %v %v", lr, err)
+	}
+}
+
+func TestGRPCWeb_preflight(t *testing.T) {
+	hs := newGRPCWebServer(t)
+	defer func() { AllowedOrigins = nil }()
+
+	req, _ := http.NewRequest(http.MethodOptions, hs.URL+"/blog.Blog/List", nil)
+	req.Header.Set("Origin", "http://admin.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web")
+	res, err := hs.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res.StatusCode != http.StatusNoContent || res.Header.Get("Access-Control-Allow-Headers") != "content-type,x-grpc-web" {
+		t.Fatalf("Unexpected preflight response: %v %v", res.StatusCode, res.Header)
+	}
+	// any origin is allowed by default, but without credentials
+	if res.Header.Get("Access-Control-Allow-Origin") != "*" || res.Header.Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatalf("Expected any origin without credentials: %v", res.Header)
+	}
+
+	AllowedOrigins = []string{"http://admin.example"}
+	res, err = hs.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res.Header.Get("Access-Control-Allow-Origin") != "http://admin.example" || res.Header.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("Expected listed origin with credentials: %v", res.Header)
+	}
+
+	AllowedOrigins = []string{"http://other.example"}
+	res, err = hs.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res.StatusCode != http.StatusForbidden || res.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("Expected origin to be rejected: %v %v", res.StatusCode, res.Header)
+	}
+}
+
+func TestGRPCWeb_native_rejected(t *testing.T) {
+	hs := newGRPCWebServer(t)
+
+	res, err := hs.Client().Post(hs.URL+"/blog.Blog/Read", "application/grpc", bytes.NewReader(nil))
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("Native gRPC should go to the gRPC listener, got %v", res.StatusCode)
+	}
+}
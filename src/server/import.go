@@ -0,0 +1,308 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"example.com/grpc/blog/src/slug"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ImportBatchSize is the default number of imported Articles written to the repo at once
+var ImportBatchSize = 100
+
+// importKeys maps upsert modes onto repo keys Articles are matched by
+var importKeys = map[pb.ImportOptions_Mode]string{
+	pb.ImportOptions_INSERT:                "",
+	pb.ImportOptions_UPSERT_BY_SLUG:        repo.KeySlug,
+	pb.ImportOptions_UPSERT_BY_EXTERNAL_ID: repo.KeyExternalID,
+}
+
+// Import reads Articles from the stream and writes them to the repo in batches
+// Articles which can't be imported are reported in the summary instead of failing the whole import
+func (s *BlogServer) Import(stream pb.Blog_ImportServer) error {
+	ctx := stream.Context()
+	var im *importer
+	for i := int64(0); ; {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if ctx.Err() == context.Canceled {
+				return status.Error(codes.Canceled, requestCancelled)
+			}
+			log.Printf("Error receiving articles to import: %v\n", err)
+			return status.Error(codes.Internal, internalError)
+		}
+		if im == nil {
+			if im, err = newImporter(s, r.GetOptions()); err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+		if r.GetArticle() == nil {
+			continue
+		}
+		im.add(ctx, i, r.GetArticle())
+		i++
+		if len(im.batch) >= im.size {
+			if err := im.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	if im == nil {
+		return stream.SendAndClose(&pb.ImportResponse{})
+	}
+	if err := im.flush(ctx); err != nil {
+		return err
+	}
+	return stream.SendAndClose(im.res)
+}
+
+// importer keeps the state of an Import
+type importer struct {
+	s *BlogServer
+	// key is the repo key Articles are upserted by, empty one inserts every Article
+	key  string
+	size int
+	// seen keys of the import, so an Article can't be imported twice
+	seen  map[string]bool
+	batch []importItem
+	res   *pb.ImportResponse
+}
+
+// importItem is an imported Article waiting to be written
+type importItem struct {
+	index int64
+	m     *models.Article
+	key   string
+	// timed is set when the publish time is imported rather than derived from the status
+	timed bool
+}
+
+// newImporter validates import options
+func newImporter(s *BlogServer, o *pb.ImportOptions) (*importer, error) {
+	key, ok := importKeys[o.GetMode()]
+	if !ok {
+		return nil, fmt.Errorf("Unknown import mode %v", o.GetMode())
+	}
+	size := int(o.GetBatchSize())
+	if size < 0 || size > MaxBatchSize {
+		return nil, fmt.Errorf("Batch size should be between 0 and %v", MaxBatchSize)
+	}
+	if size == 0 {
+		size = ImportBatchSize
+	}
+	return &importer{
+		s:    s,
+		key:  key,
+		size: size,
+		seen: make(map[string]bool),
+		res:  &pb.ImportResponse{},
+	}, nil
+}
+
+// add validates an Article and puts it into the batch
+func (im *importer) add(ctx context.Context, index int64, a *pb.Article) {
+	m, err := im.s.importArticle(ctx, a)
+	if err != nil {
+		im.fail(index, status.Convert(err).Message())
+		return
+	}
+	key := ""
+	switch im.key {
+	case repo.KeySlug:
+		key = m.Slug
+		src := a.GetSlug()
+		if src == "" {
+			src = a.GetTitle()
+		}
+		if slug.IsEmpty(src) {
+			// every such Article would get the placeholder slug and overwrite the others
+			im.fail(index, "Slug is required when the title has no letters or digits")
+			return
+		}
+	case repo.KeyExternalID:
+		key = m.ExternalID
+		if key == "" {
+			im.fail(index, "External ID is required")
+			return
+		}
+	}
+	if key != "" {
+		if im.seen[key] {
+			im.fail(index, fmt.Sprintf("Article %v is imported more than once", key))
+			return
+		}
+		im.seen[key] = true
+	}
+	im.batch = append(im.batch, importItem{index: index, m: m, key: key, timed: a.GetPublishTime() != nil})
+}
+
+// flush writes the batch, existing Articles are updated and new ones are added at once
+func (im *importer) flush(ctx context.Context) error {
+	batch := im.batch
+	im.batch = nil
+	if len(batch) == 0 {
+		return nil
+	}
+	existing := map[string]models.Article{}
+	if im.key != "" {
+		keys := make([]string, 0, len(batch))
+		for _, it := range batch {
+			keys = append(keys, it.key)
+		}
+		var err error
+		if existing, err = im.s.r.GetArticlesByKey(ctx, im.key, keys); err != nil {
+			log.Printf("Error finding imported articles: %v\n", err)
+			return status.Error(codes.Internal, internalError)
+		}
+	}
+	inserts := make([]*models.Article, 0, len(batch))
+	indexes := make([]int64, 0, len(batch))
+	for _, it := range batch {
+		old, ok := existing[it.key]
+		if !ok {
+			inserts = append(inserts, it.m)
+			indexes = append(indexes, it.index)
+			continue
+		}
+		if old.IsDeleted() {
+			im.fail(it.index, "Article is in trash")
+			continue
+		}
+		fields := changedFields(old, *it.m)
+		restatus := it.restatus(old)
+		if len(fields) == 0 && !restatus {
+			im.skip(it.index, "Article is unchanged")
+			continue
+		}
+		it.m.ID = old.ID
+		var err error
+		if len(fields) > 0 {
+			_, err = im.s.r.UpdateArticle(ctx, it.m, fields)
+		}
+		if err == nil && restatus {
+			_, err = im.s.r.SetStatus(ctx, old.ID.Hex(), it.m.Status, it.m.PublishTime)
+		}
+		if err != nil {
+			log.Printf("Error updating imported article: %v\n", err)
+			im.fail(it.index, status.Convert(repoError(err, "id")).Message())
+			continue
+		}
+		im.res.Updated++
+	}
+	errs, err := im.s.r.AddArticles(ctx, inserts)
+	if err != nil {
+		log.Println("Got error from repo.AddArticles", err)
+		return status.Error(codes.Internal, internalError)
+	}
+	for j, err := range errs {
+		if err != nil {
+			log.Printf("Error adding imported article: %v\n", err)
+			im.fail(indexes[j], status.Convert(repoError(err, "id")).Message())
+			continue
+		}
+		im.res.Inserted++
+	}
+	return nil
+}
+
+func (im *importer) fail(index int64, reason string) {
+	im.res.Failed++
+	im.res.Notes = append(im.res.Notes, &pb.ImportNote{Index: index, Failed: true, Reason: reason})
+}
+
+func (im *importer) skip(index int64, reason string) {
+	im.res.Skipped++
+	im.res.Notes = append(im.res.Notes, &pb.ImportNote{Index: index, Reason: reason})
+}
+
+// restatus checks whether status or publish time of the existing Article differ from the imported ones
+// Publish time which isn't imported is kept unless the Article becomes a draft, so re-imports don't move it
+func (it importItem) restatus(old models.Article) bool {
+	if !it.timed && it.m.Status != models.StatusDraft && !old.PublishTime.IsZero() {
+		it.m.PublishTime = old.PublishTime
+	}
+	return old.CurrentStatus() != it.m.Status || !old.PublishTime.Equal(it.m.PublishTime)
+}
+
+// changedFields returns fields which differ between the Articles
+func changedFields(old, m models.Article) []string {
+	fields := []string{}
+	for _, f := range models.AllFields {
+		ov, _ := old.Field(f)
+		nv, _ := m.Field(f)
+		if ot, ok := ov.([]string); ok {
+			if !sameStrings(ot, nv.([]string)) {
+				fields = append(fields, f)
+			}
+			continue
+		}
+		if ov != nv {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// importArticle validates the imported Article and makes a model out of it
+// Unlike Create it keeps slug, external ID, status and publish time of the Article
+func (s *BlogServer) importArticle(ctx context.Context, a *pb.Article) (*models.Article, error) {
+	st, pt, err := importStatus(a)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := contentFormat(a); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	m := articleModel(a, st, pt)
+	if err := validate(m, models.UpdatableFields, "article"); err != nil {
+		return nil, err
+	}
+	if err := s.checkAuthor(ctx, a.GetAuthorId()); err != nil {
+		return nil, err
+	}
+	if a.GetSlug() != "" {
+		m.Slug = slug.Make(a.GetSlug())
+	}
+	m.ExternalID = a.GetExternalId()
+	return m, nil
+}
+
+// importStatus returns status and publish time for an imported Article
+// Published and archived Articles keep their publish time, drafts are treated the way Create does
+func importStatus(a *pb.Article) (models.Status, time.Time, error) {
+	switch a.GetStatus() {
+	case pb.Article_PUBLISHED, pb.Article_ARCHIVED:
+		st, _ := models.StatusFromPB(a.GetStatus())
+		if a.GetPublishTime() == nil {
+			return st, models.Now(), nil
+		}
+		if err := a.GetPublishTime().CheckValid(); err != nil {
+			return "", time.Time{}, err
+		}
+		return st, a.GetPublishTime().AsTime().Truncate(time.Millisecond), nil
+	}
+	return createStatus(a)
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type testImportServer struct {
+	grpc.ServerStream
+
+	requests []*pb.ImportRequest
+	res      *pb.ImportResponse
+}
+
+func (s *testImportServer) Context() context.Context {
+	return context.Background()
+}
+
+func (s *testImportServer) Recv() (*pb.ImportRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	r := s.requests[0]
+	s.requests = s.requests[1:]
+	return r, nil
+}
+
+func (s *testImportServer) SendAndClose(m *pb.ImportResponse) error {
+	s.res = m
+	return nil
+}
+
+func runImport(t *testing.T, s *BlogServer, o *pb.ImportOptions, articles ...*pb.Article) *pb.ImportResponse {
+	ts := &testImportServer{requests: []*pb.ImportRequest{{Options: o}}}
+	for _, a := range articles {
+		ts.requests = append(ts.requests, &pb.ImportRequest{Article: a})
+	}
+	if err := s.Import(ts); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	return ts.res
+}
+
+func TestImport_upsert_by_external_id(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+	o := &pb.ImportOptions{Mode: pb.ImportOptions_UPSERT_BY_EXTERNAL_ID, BatchSize: 2}
+	published := timestamppb.New(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC))
+	articles := []*pb.Article{
+		{ExternalId: "1", AuthorId: testAuthor.Hex(), Title: "Book1", Status: pb.Article_PUBLISHED, PublishTime: published},
+		{ExternalId: "2", AuthorId: testAuthor.Hex(), Title: "Book2"},
+		{AuthorId: testAuthor.Hex(), Title: "No external ID"},
+		{ExternalId: "3", AuthorId: primitive.NewObjectID().Hex(), Title: "Book3"},
+		{ExternalId: "1", AuthorId: testAuthor.Hex(), Title: "Book1 again"},
+	}
+
+	res := runImport(t, s, o, articles...)
+	if res.Inserted != 2 || res.Failed != 3 || len(res.Notes) != 3 {
+		t.Fatalf("Wrong summary: %v", res)
+	}
+	if res.Notes[0].Index != 2 || !res.Notes[0].Failed {
+		t.Fatalf("Wrong note: %v", res.Notes[0])
+	}
+	for _, a := range m {
+		if a.ExternalID == "1" && (a.CurrentStatus() != models.StatusPublished || !a.PublishTime.Equal(published.AsTime())) {
+			t.Fatalf("Published article should keep its publish time: %v", a)
+		}
+	}
+
+	articles[1].Content = "Updated"
+	res = runImport(t, s, o, articles[:2]...)
+	if res.Inserted != 0 || res.Updated != 1 || res.Skipped != 1 {
+		t.Fatalf("Import should be idempotent: %v", res)
+	}
+	if len(m) != 2 {
+		t.Fatalf("Expected two articles, got %v", len(m))
+	}
+}
+
+func TestImport_upsert_by_slug(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+	o := &pb.ImportOptions{Mode: pb.ImportOptions_UPSERT_BY_SLUG}
+
+	for i := 0; i < 2; i++ {
+		res := runImport(t, s, o,
+			&pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"},
+			&pb.Article{AuthorId: testAuthor.Hex(), Slug: "legacy-slug", Title: "Book2"},
+			&pb.Article{AuthorId: testAuthor.Hex(), Title: "!!!"})
+		if res.Inserted+res.Skipped != 2 || res.Failed != 1 || !res.Notes[0].Failed || res.Notes[0].Index != 2 {
+			t.Fatalf("Wrong summary: %v", res)
+		}
+	}
+	if len(m) != 2 {
+		t.Fatalf("Expected two articles, got %v", len(m))
+	}
+	slugs := map[string]bool{}
+	for _, a := range m {
+		slugs[a.Slug] = true
+	}
+	if !slugs["book1"] || !slugs["legacy-slug"] {
+		t.Fatalf("Wrong slugs: %v", slugs)
+	}
+
+	res := runImport(t, s, &pb.ImportOptions{}, &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"})
+	if res.Inserted != 1 || len(m) != 3 {
+		t.Fatalf("Insert mode should always insert: %v", res)
+	}
+}
+
+func TestImport_status_change(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+	o := &pb.ImportOptions{Mode: pb.ImportOptions_UPSERT_BY_EXTERNAL_ID}
+	a := &pb.Article{ExternalId: "1", AuthorId: testAuthor.Hex(), Title: "Book1"}
+	runImport(t, s, o, a)
+
+	a.Status = pb.Article_PUBLISHED
+	res := runImport(t, s, o, a)
+	if res.Updated != 1 {
+		t.Fatalf("Publishing a draft should update it: %v", res)
+	}
+	var published models.Article
+	for _, v := range m {
+		published = v
+	}
+	if published.CurrentStatus() != models.StatusPublished || published.PublishTime.IsZero() {
+		t.Fatalf("Article should be published: %v", published)
+	}
+
+	res = runImport(t, s, o, a)
+	if res.Skipped != 1 {
+		t.Fatalf("Re-import should keep the publish time: %v", res)
+	}
+}
+
+func TestImport_repo_errors_reported(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+	a := &pb.Article{ExternalId: "1", AuthorId: testAuthor.Hex(), Title: "Book1"}
+	res := runImport(t, s, &pb.ImportOptions{}, a, a)
+	if res.Inserted != 1 || res.Failed != 1 {
+		t.Fatalf("Wrong summary: %v", res)
+	}
+	if res.Notes[0].Reason != "blog.Article already exists: 1" {
+		t.Fatalf("Conflict should be reported: %v", res.Notes[0])
+	}
+}
@@ -2,21 +2,29 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	pb "example.com/grpc/blog/gen/src"
 	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/render"
 	"example.com/grpc/blog/src/repo"
+	"example.com/grpc/blog/src/slug"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 const (
 	internalError    = "There was an error internally"
 	requestCancelled = "Client cancelled request, aborting"
+	invalidPageToken = "Page token is invalid"
+	invalidSortField = "Sort field is not supported"
 )
 
 // ListTimeout controls how much time List waits until cancelling
@@ -26,106 +34,332 @@ var ListTimeout time.Duration = time.Duration(5 * time.Second)
 type BlogServer struct {
 	pb.UnimplementedBlogServer
 
-	r repo.ArticleRepo
+	r     repo.ArticleRepo
+	au    repo.AuthorRepo
+	html  *render.Cache
+	feeds *feedLog
 }
 
 // NewBlogServer returns a blogServer
-func NewBlogServer(r repo.ArticleRepo) *BlogServer {
+func NewBlogServer(r repo.ArticleRepo, au repo.AuthorRepo) *BlogServer {
 	return &BlogServer{
-		r: r,
+		r:     r,
+		au:    au,
+		html:  render.NewCache(RenderCacheSize),
+		feeds: newFeedLog(),
 	}
 }
 
 // Create implements the Create method for our Blog
+// Articles are created as drafts unless they're explicitly published
 func (s *BlogServer) Create(ctx context.Context, r *pb.CreateRequest) (*pb.CreateResponse, error) {
-	a := r.GetArticle()
-	// need to create an Article since ID should be skipped
-	m := &models.Article{
-		ID:       primitive.NilObjectID,
-		AuthorID: a.GetAuthorId(),
-		Title:    a.GetTitle(),
-		Content:  a.GetContent(),
-	}
-	id, err := s.r.AddArticle(ctx, m)
+	m, err := s.newArticle(ctx, r.GetArticle(), "article")
+	if err != nil {
+		return nil, err
+	}
+	// repo fills ID and timestamps of m and makes the slug unique
+	_, err = s.r.AddArticle(ctx, m)
 	if err != nil {
 		log.Println("Got error from repo.AddArticle", err)
-		return nil, status.Error(codes.Internal, internalError)
+		return nil, repoError(err, "article.id")
 	}
 
 	if ctx.Err() == context.Canceled {
 		return nil, status.Error(codes.Canceled, requestCancelled)
 	}
-	a.Id = id
-	return &pb.CreateResponse{Article: a}, status.Error(codes.OK, "Successfully created the article")
+	return &pb.CreateResponse{Article: m.ToPB()}, status.Error(codes.OK, "Successfully created the article")
+}
+
+// newArticle validates the requested Article and makes a model to add out of it
+// field is the path of the Article in the request
+func (s *BlogServer) newArticle(ctx context.Context, a *pb.Article, field string) (*models.Article, error) {
+	st, pt, err := createStatus(a)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := contentFormat(a); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	m := articleModel(a, st, pt)
+	if err := validate(m, models.UpdatableFields, field); err != nil {
+		return nil, err
+	}
+	if err := s.checkAuthor(ctx, a.GetAuthorId()); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-// Read returns one Article Doc
+// articleModel makes a new Article model with the status, tags are kept as requested until validated
+// Content format should be validated beforehand
+func articleModel(a *pb.Article, st models.Status, pt time.Time) *models.Article {
+	f, _ := contentFormat(a)
+	// need to create an Article since ID and timestamps should be skipped
+	return &models.Article{
+		ID:            primitive.NilObjectID,
+		AuthorID:      a.GetAuthorId(),
+		Title:         a.GetTitle(),
+		Content:       a.GetContent(),
+		Tags:          a.GetTags(),
+		ContentFormat: f,
+		Slug:          slug.Make(a.GetTitle()),
+		Status:        st,
+		PublishTime:   pt,
+	}
+}
+
+// createStatus returns status and publish time for a new Article
+// Drafts may be scheduled with publish time, published Articles are published now
+func createStatus(a *pb.Article) (models.Status, time.Time, error) {
+	switch a.GetStatus() {
+	case pb.Article_STATUS_UNSPECIFIED, pb.Article_DRAFT:
+		if a.GetPublishTime() == nil {
+			return models.StatusDraft, time.Time{}, nil
+		}
+		if err := a.GetPublishTime().CheckValid(); err != nil {
+			return "", time.Time{}, err
+		}
+		return models.StatusDraft, a.GetPublishTime().AsTime().Truncate(time.Millisecond), nil
+	case pb.Article_PUBLISHED:
+		return models.StatusPublished, models.Now(), nil
+	}
+	return "", time.Time{}, fmt.Errorf("Articles can't be created with status %v", a.GetStatus())
+}
+
+// Read returns one Article Doc, or its past version when revision ID is given
 func (s *BlogServer) Read(ctx context.Context, r *pb.ReadRequest) (*pb.ReadResponse, error) {
+	if r.GetRevisionId() != "" {
+		rev, err := s.r.GetRevision(ctx, r.GetId(), r.GetRevisionId())
+		if err != nil {
+			log.Printf("Error while reading revision: %v\n", err)
+			return nil, repoError(err, "id")
+		}
+		if ctx.Err() == context.Canceled {
+			return nil, status.Error(codes.Canceled, requestCancelled)
+		}
+		return &pb.ReadResponse{Article: s.withAuthor(ctx, s.withHTML(rev.Article.ToPB()), r.GetIncludeAuthor())}, nil
+	}
 	m, err := s.r.GetArticle(ctx, r.GetId())
 	if err != nil {
 		log.Printf("Error while reading: %v\n", err)
-		return nil, status.Error(codes.Internal, internalError)
+		return nil, repoError(err, "id")
 	}
 	if ctx.Err() == context.Canceled {
 		return nil, status.Error(codes.Canceled, requestCancelled)
 	}
-	return &pb.ReadResponse{Article: m.ToPB()}, nil
+	return &pb.ReadResponse{Article: s.withAuthor(ctx, s.withHTML(m.ToPB()), r.GetIncludeAuthor())}, nil
 }
 
-// List streams Articles
+// ReadBySlug returns one Article Doc by its slug
+func (s *BlogServer) ReadBySlug(ctx context.Context, r *pb.ReadBySlugRequest) (*pb.ReadResponse, error) {
+	m, err := s.r.GetArticleBySlug(ctx, r.GetSlug())
+	if err != nil {
+		log.Printf("Error while reading by slug: %v\n", err)
+		return nil, repoError(err, "slug")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.ReadResponse{Article: s.withAuthor(ctx, s.withHTML(m.ToPB()), r.GetIncludeAuthor())}, nil
+}
+
+// List streams Articles page by page
+// The last message of a page carries the token for the next one
 func (s *BlogServer) List(r *pb.ListRequest, stream pb.Blog_ListServer) error {
+	q, err := listQuery(r)
+	if err != nil {
+		log.Printf("Error parsing list request: %v", err)
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.streamArticles(q, r.GetIncludeAuthor(), stream)
+}
+
+// listSender is implemented by streams of List like RPCs
+type listSender interface {
+	Send(*pb.ListResponse) error
+}
+
+// streamArticles sends Articles matching the query to the stream
+// Author profiles are embedded when includeAuthor is set
+func (s *BlogServer) streamArticles(q repo.ArticleQuery, includeAuthor bool, stream listSender) error {
 	ctx, cancel := context.WithTimeout(context.Background(), ListTimeout)
 	defer cancel()
-	stop := make(chan struct{})
+	// Articles of the same author share the profile
+	authors := make(map[string]*pb.Author)
 	out := make(chan models.Article)
-	e := make(chan error)
-	go func() {
-		defer close(e)
-		for v := range out {
-			if ctx.Err() == context.DeadlineExceeded {
-				interruptList("Exceeded deadline", out, stop, e, codes.DeadlineExceeded)
-				return
+	return streamPage(ctx, page{
+		limit: q.Limit,
+		fill: func(stop <-chan struct{}) error {
+			return s.r.FillArticles(ctx, q, out, stop)
+		},
+		recv: func() (interface{}, bool) {
+			v, ok := <-out
+			return v, ok
+		},
+		token: func(v interface{}) string {
+			return encodePageToken(q.CursorFor(v.(models.Article)))
+		},
+		send: func(v interface{}, next string) error {
+			pa := v.(models.Article).ToPB()
+			// rendering the omitted content would cache it as the version's HTML
+			if !q.Summary {
+				s.withHTML(pa)
 			}
-			if err := stream.Send(&pb.ListResponse{Article: v.ToPB()}); err != nil {
-				interruptList(fmt.Sprintf("Got error while sending: %v", err), out, stop, e, codes.Internal)
-				return
+			if includeAuthor && pa.AuthorId != "" {
+				au, ok := authors[pa.AuthorId]
+				if !ok {
+					au = s.authorPB(ctx, pa.AuthorId)
+					authors[pa.AuthorId] = au
+				}
+				pa.Author = au
+			}
+			return stream.Send(&pb.ListResponse{Article: pa, NextPageToken: next})
+		},
+	})
+}
+
+// listQuery builds repo query out of List request
+func listQuery(r *pb.ListRequest) (repo.ArticleQuery, error) {
+	f := r.GetFilter()
+	q := repo.ArticleQuery{
+		Filter: repo.ArticleFilter{
+			AuthorID:        f.GetAuthorId(),
+			TitlePrefix:     f.GetTitlePrefix(),
+			TitleContains:   f.GetTitleContains(),
+			ContentContains: f.GetContentContains(),
+			Tags:            models.NormalizeTags(f.GetTags()),
+			Statuses:        []models.Status{models.StatusPublished},
+		},
+		Desc:    r.GetSort().GetDescending(),
+		Summary: r.GetSummaryOnly(),
+	}
+	if len(f.GetStatuses()) > 0 {
+		q.Filter.Statuses = make([]models.Status, 0, len(f.GetStatuses()))
+		for _, ps := range f.GetStatuses() {
+			st, ok := models.StatusFromPB(ps)
+			if !ok {
+				return q, fmt.Errorf("Unknown status %v", ps)
 			}
+			q.Filter.Statuses = append(q.Filter.Statuses, st)
 		}
-		// send signal to close "stop" goroutine
-		stop <- struct{}{}
-		e <- nil
-	}()
-	err := s.r.FillArticles(ctx, out, stop)
+	}
+	sf, ok := sortFields[r.GetSort().GetField()]
+	if !ok {
+		return q, errors.New(invalidSortField)
+	}
+	q.Sort = sf
+	return q, setPage(&q, r.GetPageSize(), r.GetPageToken())
+}
+
+// setPage sets limit and cursor of the query, the cursor must be made for the sort of the query
+func setPage(q *repo.ArticleQuery, size int32, token string) error {
+	limit, c, err := parsePage(size, token, q.CheckCursor)
+	if err != nil {
+		return err
+	}
+	q.Limit = limit
+	if c != nil {
+		q.After = c
+	}
+	return nil
+}
+
+// parsePage returns the limit and the cursor of the page of the size after the token
+// one extra item is requested to find out if there's a next page, zero limit means no limit
+// check rejects cursors made for another listing, it may be nil
+func parsePage(size int32, token string, check func(repo.Cursor) error) (int64, *repo.Cursor, error) {
+	if size < 0 {
+		return 0, nil, errors.New("Page size can't be negative")
+	}
+	limit := int64(0)
+	if size > 0 {
+		limit = int64(size) + 1
+	}
+	if token == "" {
+		return limit, nil, nil
+	}
+	c, err := decodePageToken(token)
+	if err == nil && check != nil {
+		err = check(*c)
+	}
 	if err != nil {
-		log.Printf("Error when filling out channel: %v", err)
-		return status.Error(codes.Internal, internalError)
+		log.Printf("Error decoding page token: %v", err)
+		return 0, nil, errors.New(invalidPageToken)
 	}
+	return limit, c, nil
+}
+
+// sortFields maps Sort fields onto repo ones
+var sortFields = map[pb.Sort_Field]repo.SortField{
+	pb.Sort_ID:           repo.SortByID,
+	pb.Sort_TITLE:        repo.SortByTitle,
+	pb.Sort_AUTHOR_ID:    repo.SortByAuthor,
+	pb.Sort_CREATE_TIME:  repo.SortByCreateTime,
+	pb.Sort_UPDATE_TIME:  repo.SortByUpdateTime,
+	pb.Sort_PUBLISH_TIME: repo.SortByPublishTime,
+}
+
+// pageToken is the content of opaque page token
+type pageToken struct {
+	ID   string         `json:"id"`
+	Key  string         `json:"k,omitempty"`
+	Sort repo.SortField `json:"s,omitempty"`
+	Desc bool           `json:"d,omitempty"`
+}
 
-	return <-e
+// encodePageToken makes opaque token out of Cursor
+func encodePageToken(c repo.Cursor) string {
+	b, _ := json.Marshal(pageToken{ID: c.ID.Hex(), Key: c.Key, Sort: c.Sort, Desc: c.Desc})
+	return base64.RawURLEncoding.EncodeToString(b)
 }
 
-// inerruptList used to interrup Fetching of new Articles
-func interruptList(msg string, out <-chan models.Article, stop chan<- struct{}, e chan<- error, code codes.Code) {
-	// dump last value from out
-	// Otherwise we're stuck because message was already sent and waiting for next iteration to read it
-	// before "interrupt" signal can be read
-	<-out
-	stop <- struct{}{}
-	log.Print(msg)
-	e <- status.Errorf(code, internalError)
+// decodePageToken restores Cursor from the token
+func decodePageToken(t string) (*repo.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(t)
+	if err != nil {
+		return nil, err
+	}
+	pt := pageToken{}
+	if err := json.Unmarshal(b, &pt); err != nil {
+		return nil, err
+	}
+	oid, err := primitive.ObjectIDFromHex(pt.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &repo.Cursor{ID: oid, Key: pt.Key, Sort: pt.Sort, Desc: pt.Desc}, nil
 }
 
 // Update an Article and returns result with updated Article
 func (s *BlogServer) Update(ctx context.Context, r *pb.UpdateRequest) (*pb.UpdateResponse, error) {
 	m, err := models.FromPB(r.GetArticle())
 	if err != nil {
-		log.Printf("Error updating article: %v\n", err)
-		return nil, status.Error(codes.Internal, internalError)
+		return nil, invalidID("article.id", r.GetArticle().GetId())
+	}
+	fields, err := updateFields(r.GetUpdateMask())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := validate(m, fields, "article"); err != nil {
+		return nil, err
 	}
-	res, err := s.r.UpdateArticle(ctx, m)
+	for _, f := range fields {
+		switch f {
+		case models.FieldAuthorID:
+			if err := s.checkAuthor(ctx, m.AuthorID); err != nil {
+				return nil, err
+			}
+		case models.FieldContentFormat:
+			if _, err := contentFormat(r.GetArticle()); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+		}
+	}
+	m.Version = r.GetExpectedVersion()
+	res, err := s.r.UpdateArticle(ctx, m, fields)
 	if err != nil {
 		log.Printf("Error updating article: %v\n", err)
-		return nil, status.Error(codes.Internal, internalError)
+		return nil, repoError(err, "article.id")
 	}
 	if ctx.Err() == context.Canceled {
 		return nil, status.Error(codes.Canceled, requestCancelled)
@@ -133,12 +367,27 @@ func (s *BlogServer) Update(ctx context.Context, r *pb.UpdateRequest) (*pb.Updat
 	return &pb.UpdateResponse{Article: res.ToPB()}, status.Error(codes.OK, "Successfully updated Article")
 }
 
-// Delete an Article by ID
+// updateFields validates update mask and returns Article fields to update
+func updateFields(mask *fieldmaskpb.FieldMask) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return models.UpdatableFields, nil
+	}
+	fields := make([]string, 0, len(mask.GetPaths()))
+	for _, p := range mask.GetPaths() {
+		if _, ok := (models.Article{}).Field(p); !ok {
+			return nil, fmt.Errorf("Unknown update mask path: %v", p)
+		}
+		fields = append(fields, p)
+	}
+	return fields, nil
+}
+
+// Delete moves an Article by ID to trash
 func (s *BlogServer) Delete(ctx context.Context, r *pb.DeleteRequest) (*pb.DeleteResponse, error) {
-	m, err := s.r.DeleteArticle(ctx, r.GetId())
+	m, err := s.r.DeleteArticle(ctx, r.GetId(), r.GetExpectedVersion())
 	if err != nil {
 		log.Printf("Error deleting article: %v\n", err)
-		return nil, status.Error(codes.Internal, internalError)
+		return nil, repoError(err, "id")
 	}
 	if ctx.Err() == context.Canceled {
 		return nil, status.Error(codes.Canceled, requestCancelled)
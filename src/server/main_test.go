@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/hex"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,19 +15,31 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// testAuthor writes Articles of tests, Articles can't be stored without an existing author
+var testAuthor = primitive.NewObjectID()
+
+func testAuthors() *repo.MapAuthorRepo {
+	return repo.NewMapAuthorRepo(map[primitive.ObjectID]models.Author{testAuthor: {ID: testAuthor, DisplayName: "Tester"}})
+}
+
 func TestCreate_success(t *testing.T) {
+	au := make(map[primitive.ObjectID]models.Author)
+	bob := primitive.NewObjectID()
+	au[bob] = models.Author{ID: bob, DisplayName: "Bob"}
 	r := &pb.CreateRequest{
 		Article: &pb.Article{
 			Id:       "",
-			AuthorId: "Bob",
+			AuthorId: bob.Hex(),
 			Title:    "Book1",
 			Content:  "Once upon a time",
 		},
 	}
 
-	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)))
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), repo.NewMapAuthorRepo(au))
 
 	res, err := s.Create(context.Background(), r)
 
@@ -41,6 +54,48 @@ func TestCreate_success(t *testing.T) {
 	}
 }
 
+func TestCreate_timestamps(t *testing.T) {
+	r := &pb.CreateRequest{
+		Article: &pb.Article{
+			AuthorId:   testAuthor.Hex(),
+			Title:      "Book1",
+			CreateTime: timestamppb.New(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	before := time.Now().Add(-time.Second)
+	res, err := s.Create(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if !res.Article.CreateTime.AsTime().After(before) {
+		t.Fatalf("Create time was not set by the server: %v", res.Article.CreateTime.AsTime())
+	}
+	if !res.Article.UpdateTime.AsTime().Equal(res.Article.CreateTime.AsTime()) {
+		t.Fatalf("Update time %v differs from create time %v", res.Article.UpdateTime.AsTime(), res.Article.CreateTime.AsTime())
+	}
+
+	created := res.Article.CreateTime.AsTime()
+	time.Sleep(2 * time.Millisecond)
+	u, err := s.Update(context.Background(), &pb.UpdateRequest{Article: &pb.Article{
+		Id:         res.Article.Id,
+		AuthorId:   testAuthor.Hex(),
+		Title:      "Book1_updated",
+		CreateTime: timestamppb.New(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if !u.Article.CreateTime.AsTime().Equal(created) {
+		t.Fatalf("Create time changed to %v", u.Article.CreateTime.AsTime())
+	}
+	if !u.Article.UpdateTime.AsTime().After(created) {
+		t.Fatalf("Update time was not bumped: %v", u.Article.UpdateTime.AsTime())
+	}
+}
+
 type mapRepoWithCreateError struct {
 	repo.MapArticleRepo
 }
@@ -51,11 +106,12 @@ func (r *mapRepoWithCreateError) AddArticle(context.Context, *models.Article) (s
 
 func TestCreate_repo_error(t *testing.T) {
 	r := &pb.CreateRequest{
-		Article: &pb.Article{},
+		Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"},
 	}
 
 	s := BlogServer{
-		r: &mapRepoWithCreateError{},
+		r:  &mapRepoWithCreateError{},
+		au: testAuthors(),
 	}
 	res, err := s.Create(context.Background(), r)
 
@@ -73,11 +129,12 @@ func TestCreate_repo_error(t *testing.T) {
 
 func TestCreate_context_cancelled(t *testing.T) {
 	r := &pb.CreateRequest{
-		Article: &pb.Article{},
+		Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"},
 	}
 
 	s := BlogServer{
-		r: repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)),
+		r:  repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)),
+		au: testAuthors(),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -123,6 +180,64 @@ func TestRead_success(t *testing.T) {
 	}
 }
 
+func TestCreate_slug(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	tests := []struct {
+		title string
+		slug  string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"Hello world", "hello-world-2"},
+		{"  hello -- WORLD ", "hello-world-3"},
+		{"Crème brûlée für Straße", "creme-brulee-fur-strasse"},
+		{"Привет, мир", "privet-mir"},
+		{"!!!", "article"},
+	}
+	for _, tt := range tests {
+		res, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: tt.title, Slug: "ignored"}})
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+		if res.Article.Slug != tt.slug {
+			t.Errorf("Expected slug of %q to be %q, got %q", tt.title, tt.slug, res.Article.Slug)
+		}
+	}
+}
+
+func TestReadBySlug(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book2", Status: pb.Article_PUBLISHED}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Draft"}}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	res, err := s.ReadBySlug(context.Background(), &pb.ReadBySlugRequest{Slug: "book2"})
+	if err != nil {
+		t.Fatalf("Got eror: %v", err)
+	}
+	if res.Article.Id != c.Article.Id {
+		t.Fatalf("Got wrong article: %v", res.Article)
+	}
+
+	res, err = s.ReadBySlug(context.Background(), &pb.ReadBySlugRequest{Slug: "book3"})
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if err == nil {
+		t.Fatal("Expected error for missing slug")
+	}
+
+	_, err = s.ReadBySlug(context.Background(), &pb.ReadBySlugRequest{Slug: "draft"})
+	if se, ok := status.FromError(err); !ok || se.Code() != codes.NotFound {
+		t.Fatalf("Drafts should not be read by slug, got %v", err)
+	}
+}
+
 type mapRepoWithReadError struct {
 	repo.MapArticleRepo
 }
@@ -192,8 +307,9 @@ func TestUpdate_success(t *testing.T) {
 	h := hex.EncodeToString([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
 	r := &pb.UpdateRequest{
 		Article: &pb.Article{
-			Id:    h,
-			Title: "Book2_updated",
+			Id:       h,
+			AuthorId: testAuthor.Hex(),
+			Title:    "Book2_updated",
 		},
 	}
 
@@ -205,7 +321,8 @@ func TestUpdate_success(t *testing.T) {
 	}
 
 	s := BlogServer{
-		r: repo.NewMapRepo(m),
+		r:  repo.NewMapRepo(m),
+		au: testAuthors(),
 	}
 
 	res, err := s.Update(context.Background(), r)
@@ -217,11 +334,68 @@ func TestUpdate_success(t *testing.T) {
 	}
 }
 
+func TestUpdate_mask(t *testing.T) {
+	h := hex.EncodeToString([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	r := &pb.UpdateRequest{
+		Article: &pb.Article{
+			Id:       h,
+			AuthorId: testAuthor.Hex(),
+			Title:    "Book2_updated",
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+	}
+
+	m := make(map[primitive.ObjectID]models.Article)
+	oid, _ := primitive.ObjectIDFromHex(h)
+	m[oid] = models.Article{
+		ID:       oid,
+		AuthorID: "Bob",
+		Title:    "Book2",
+		Content:  "Once upon a time",
+	}
+
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+
+	res, err := s.Update(context.Background(), r)
+	if err != nil {
+		t.Fatalf("Got eror: %v", err)
+	}
+	if res.Article.Title != "Book2_updated" || res.Article.Content != "Once upon a time" || res.Article.AuthorId != "Bob" {
+		t.Fatalf("Got wrong article: %v", res.Article)
+	}
+	if m[oid].Content != "Once upon a time" || m[oid].Title != "Book2_updated" {
+		t.Fatalf("Wrong article stored: %v", m[oid])
+	}
+}
+
+func TestUpdate_mask_unknown_path(t *testing.T) {
+	h := hex.EncodeToString([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	r := &pb.UpdateRequest{
+		Article: &pb.Article{
+			Id:       h,
+			AuthorId: testAuthor.Hex(),
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title", "id"}},
+	}
+
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), repo.NewMapAuthorRepo(make(map[primitive.ObjectID]models.Author)))
+
+	res, err := s.Update(context.Background(), r)
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.InvalidArgument {
+		t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
+	}
+}
+
 type mapRepoWithUpdateError struct {
 	repo.MapArticleRepo
 }
 
-func (r *mapRepoWithUpdateError) UpdateArticle(context.Context, *models.Article) (*models.Article, error) {
+func (r *mapRepoWithUpdateError) UpdateArticle(context.Context, *models.Article, []string) (*models.Article, error) {
 	return nil, errors.New("Update error")
 }
 
@@ -230,7 +404,7 @@ func TestUpdate_error_empty_article(t *testing.T) {
 		Article: &pb.Article{},
 	}
 
-	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)))
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), repo.NewMapAuthorRepo(make(map[primitive.ObjectID]models.Author)))
 
 	res, err := s.Update(context.Background(), r)
 	if res != nil {
@@ -238,10 +412,8 @@ func TestUpdate_error_empty_article(t *testing.T) {
 	}
 	if se, ok := status.FromError(err); !ok {
 		t.Error("Could not initialize status from error")
-	} else if se.Code() != codes.Internal {
-		t.Errorf("Error status code is not %v, it's %v", codes.Internal.String(), se.Code().String())
-	} else if se.Message() != internalError {
-		t.Errorf("Wrong message: \"%v\"", se.Message())
+	} else if se.Code() != codes.InvalidArgument {
+		t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
 	}
 }
 
@@ -249,13 +421,15 @@ func TestUpdate_error_repo(t *testing.T) {
 	h := hex.EncodeToString([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
 	r := &pb.UpdateRequest{
 		Article: &pb.Article{
-			Id:    h,
-			Title: "Book2_updated",
+			Id:       h,
+			AuthorId: testAuthor.Hex(),
+			Title:    "Book2_updated",
 		},
 	}
 
 	s := BlogServer{
-		r: &mapRepoWithUpdateError{},
+		r:  &mapRepoWithUpdateError{},
+		au: testAuthors(),
 	}
 
 	res, err := s.Update(context.Background(), r)
@@ -275,8 +449,9 @@ func TestUpdate_context_cancelled(t *testing.T) {
 	h := hex.EncodeToString([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
 	r := &pb.UpdateRequest{
 		Article: &pb.Article{
-			Id:    h,
-			Title: "Book2_updated",
+			Id:       h,
+			AuthorId: testAuthor.Hex(),
+			Title:    "Book2_updated",
 		},
 	}
 
@@ -288,7 +463,8 @@ func TestUpdate_context_cancelled(t *testing.T) {
 	}
 
 	s := BlogServer{
-		r: repo.NewMapRepo(m),
+		r:  repo.NewMapRepo(m),
+		au: testAuthors(),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -332,7 +508,76 @@ func TestDelete_success(t *testing.T) {
 	if res.Id != h {
 		t.Fatalf("Got wrong article: %v", res.Id)
 	}
-	if len(m) > 0 {
+	if m[oid].DeleteTime.IsZero() {
+		t.Fatal("Article was not deleted")
+	}
+}
+
+func TestUpdate_version_conflict(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if c.Article.Version != 1 {
+		t.Fatalf("Expected version 1, got %v", c.Article.Version)
+	}
+
+	u, err := s.Update(context.Background(), &pb.UpdateRequest{
+		Article:         &pb.Article{Id: c.Article.Id, AuthorId: testAuthor.Hex(), Title: "Book1_first_editor"},
+		ExpectedVersion: 1,
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if u.Article.Version != 2 {
+		t.Fatalf("Expected version 2, got %v", u.Article.Version)
+	}
+
+	res, err := s.Update(context.Background(), &pb.UpdateRequest{
+		Article:         &pb.Article{Id: c.Article.Id, AuthorId: testAuthor.Hex(), Title: "Book1_second_editor"},
+		ExpectedVersion: 1,
+	})
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.Aborted {
+		t.Errorf("Error status code is not %v, it's %v", codes.Aborted.String(), se.Code().String())
+	} else if !strings.Contains(se.Message(), "current version is 2") {
+		t.Errorf("Wrong message: \"%v\"", se.Message())
+	}
+}
+
+func TestDelete_version_conflict(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	res, err := s.Delete(context.Background(), &pb.DeleteRequest{Id: c.Article.Id, ExpectedVersion: 5})
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.Aborted {
+		t.Errorf("Error status code is not %v, it's %v", codes.Aborted.String(), se.Code().String())
+	}
+	oid, _ := primitive.ObjectIDFromHex(c.Article.Id)
+	if !m[oid].DeleteTime.IsZero() {
+		t.Fatal("Article should not be deleted")
+	}
+
+	if _, err := s.Delete(context.Background(), &pb.DeleteRequest{Id: c.Article.Id, ExpectedVersion: 1}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if m[oid].DeleteTime.IsZero() {
 		t.Fatal("Article was not deleted")
 	}
 }
@@ -341,7 +586,7 @@ type mapRepoWithDeleteError struct {
 	repo.MapArticleRepo
 }
 
-func (r *mapRepoWithDeleteError) DeleteArticle(context.Context, string) (*models.Article, error) {
+func (r *mapRepoWithDeleteError) DeleteArticle(context.Context, string, int64) (*models.Article, error) {
 	return nil, errors.New("Delete error")
 }
 
@@ -352,7 +597,7 @@ func TestDelete_error(t *testing.T) {
 	}
 
 	s := BlogServer{
-		r: &mapRepoWithReadError{},
+		r: &mapRepoWithDeleteError{},
 	}
 
 	res, err := s.Delete(context.Background(), r)
@@ -406,10 +651,12 @@ type testServer struct {
 	grpc.ServerStream
 
 	articles []*pb.Article
+	next     string
 }
 
 func (s *testServer) Send(m *pb.ListResponse) error {
 	s.articles = append(s.articles, m.Article)
+	s.next = m.NextPageToken
 	return nil
 }
 
@@ -545,7 +792,7 @@ type mapRepoWithFillError struct {
 	repo.MapArticleRepo
 }
 
-func (r *mapRepoWithFillError) FillArticles(context.Context, chan<- models.Article, <-chan struct{}) error {
+func (r *mapRepoWithFillError) FillArticles(context.Context, repo.ArticleQuery, chan<- models.Article, <-chan struct{}) error {
 	return errors.New("Fill error")
 }
 
@@ -570,3 +817,189 @@ func TestList_error_filling(t *testing.T) {
 		t.Fatalf("Wrong number of values in slice. Expected 0, slice: %v", ts.articles)
 	}
 }
+
+func TestList_pagination(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article, 3)
+	for i, title := range []string{"Book1", "Book2", "Book3"} {
+		oid, _ := primitive.ObjectIDFromHex(hex.EncodeToString([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, byte(i + 1)}))
+		m[oid] = models.Article{
+			ID:    oid,
+			Title: title,
+		}
+	}
+
+	s := BlogServer{
+		r: repo.NewMapRepo(m),
+	}
+
+	ts := &testServer{articles: []*pb.Article{}}
+	err := s.List(&pb.ListRequest{PageSize: 2}, ts)
+	if err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	if len(ts.articles) != 2 || ts.articles[0].Title != "Book1" || ts.articles[1].Title != "Book2" {
+		t.Fatalf("Wrong first page: %v", ts.articles)
+	}
+	if ts.next == "" {
+		t.Fatal("Expected next page token on the last message")
+	}
+
+	ts2 := &testServer{articles: []*pb.Article{}}
+	err = s.List(&pb.ListRequest{PageSize: 2, PageToken: ts.next}, ts2)
+	if err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	if len(ts2.articles) != 1 || ts2.articles[0].Title != "Book3" {
+		t.Fatalf("Wrong second page: %v", ts2.articles)
+	}
+	if ts2.next != "" {
+		t.Fatalf("Expected no next page token, got %v", ts2.next)
+	}
+}
+
+func TestList_invalid_page_token(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), repo.NewMapAuthorRepo(make(map[primitive.ObjectID]models.Author)))
+
+	ts := &testServer{articles: []*pb.Article{}}
+	err := s.List(&pb.ListRequest{PageToken: "not a token"}, ts)
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.InvalidArgument {
+		t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
+	}
+}
+
+func TestList_page_token_of_another_sort(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article, 2)
+	for _, title := range []string{"Book1", "Book2"} {
+		oid := primitive.NewObjectID()
+		m[oid] = models.Article{ID: oid, Title: title, CreateTime: models.Now()}
+	}
+	s := BlogServer{
+		r: repo.NewMapRepo(m),
+	}
+	ts := &testServer{articles: []*pb.Article{}}
+	if err := s.List(&pb.ListRequest{PageSize: 1, Sort: &pb.Sort{Field: pb.Sort_TITLE}}, ts); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+
+	for _, sort := range []*pb.Sort{
+		{Field: pb.Sort_CREATE_TIME},
+		{Field: pb.Sort_TITLE, Descending: true},
+	} {
+		err := s.List(&pb.ListRequest{PageSize: 1, PageToken: ts.next, Sort: sort}, &testServer{articles: []*pb.Article{}})
+		if se, ok := status.FromError(err); !ok || se.Code() != codes.InvalidArgument {
+			t.Errorf("Expected %v for token of another sort than %v, got %v", codes.InvalidArgument.String(), sort, err)
+		}
+	}
+}
+
+func TestList_filter(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article, 3)
+	for i, a := range []models.Article{
+		{AuthorID: "Bob", Title: "Go basics", Content: "Once upon a time"},
+		{AuthorID: "Bob", Title: "Advanced Go", Content: "Channels and goroutines"},
+		{AuthorID: "Alice", Title: "Go tips", Content: "Use channels"},
+	} {
+		oid, _ := primitive.ObjectIDFromHex(hex.EncodeToString([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, byte(i + 1)}))
+		a.ID = oid
+		m[oid] = a
+	}
+
+	s := BlogServer{
+		r: repo.NewMapRepo(m),
+	}
+
+	tests := []struct {
+		filter *pb.ArticleFilter
+		titles []string
+	}{
+		{&pb.ArticleFilter{AuthorId: "Bob"}, []string{"Go basics", "Advanced Go"}},
+		{&pb.ArticleFilter{TitlePrefix: "Go"}, []string{"Go basics", "Go tips"}},
+		{&pb.ArticleFilter{TitleContains: "advanced"}, []string{"Advanced Go"}},
+		{&pb.ArticleFilter{AuthorId: "Bob", ContentContains: "CHANNELS"}, []string{"Advanced Go"}},
+	}
+	for _, tt := range tests {
+		ts := &testServer{articles: []*pb.Article{}}
+		if err := s.List(&pb.ListRequest{Filter: tt.filter}, ts); err != nil {
+			t.Fatalf("Got error back: %v", err)
+		}
+		if len(ts.articles) != len(tt.titles) {
+			t.Fatalf("Filter %v: expected %v, got %v", tt.filter, tt.titles, ts.articles)
+		}
+		for i, a := range ts.articles {
+			if a.Title != tt.titles[i] {
+				t.Errorf("Filter %v: expected %v, got %v", tt.filter, tt.titles[i], a.Title)
+			}
+		}
+	}
+}
+
+func TestList_sort(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article, 4)
+	for i, a := range []models.Article{
+		{AuthorID: "Carol", Title: "B"},
+		{AuthorID: "Alice", Title: "A"},
+		{AuthorID: "Bob", Title: "B"},
+		{AuthorID: "Alice", Title: "C"},
+	} {
+		oid, _ := primitive.ObjectIDFromHex(hex.EncodeToString([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, byte(i + 1)}))
+		a.ID = oid
+		m[oid] = a
+	}
+
+	s := BlogServer{
+		r: repo.NewMapRepo(m),
+	}
+
+	tests := []struct {
+		sort    *pb.Sort
+		authors []string
+	}{
+		{nil, []string{"Carol", "Alice", "Bob", "Alice"}},
+		{&pb.Sort{Descending: true}, []string{"Alice", "Bob", "Alice", "Carol"}},
+		{&pb.Sort{Field: pb.Sort_TITLE}, []string{"Alice", "Carol", "Bob", "Alice"}},
+		{&pb.Sort{Field: pb.Sort_TITLE, Descending: true}, []string{"Alice", "Bob", "Carol", "Alice"}},
+		{&pb.Sort{Field: pb.Sort_AUTHOR_ID}, []string{"Alice", "Alice", "Bob", "Carol"}},
+	}
+	for _, tt := range tests {
+		// page through one Article at a time to check cursors as well
+		got := []string{}
+		token := ""
+		for {
+			ts := &testServer{articles: []*pb.Article{}}
+			if err := s.List(&pb.ListRequest{PageSize: 1, PageToken: token, Sort: tt.sort}, ts); err != nil {
+				t.Fatalf("Got error back: %v", err)
+			}
+			for _, a := range ts.articles {
+				got = append(got, a.AuthorId)
+			}
+			if ts.next == "" {
+				break
+			}
+			token = ts.next
+		}
+		if len(got) != len(tt.authors) {
+			t.Fatalf("Sort %v: expected %v, got %v", tt.sort, tt.authors, got)
+		}
+		for i := range got {
+			if got[i] != tt.authors[i] {
+				t.Fatalf("Sort %v: expected %v, got %v", tt.sort, tt.authors, got)
+			}
+		}
+	}
+}
+
+func TestList_invalid_sort_field(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), repo.NewMapAuthorRepo(make(map[primitive.ObjectID]models.Author)))
+
+	ts := &testServer{articles: []*pb.Article{}}
+	err := s.List(&pb.ListRequest{Sort: &pb.Sort{Field: 42}}, ts)
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.InvalidArgument {
+		t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
+	} else if se.Message() != invalidSortField {
+		t.Errorf("Wrong message: \"%v\"", se.Message())
+	}
+}
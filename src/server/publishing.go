@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Publish publishes an Article now or schedules it when publish time is in future
+func (s *BlogServer) Publish(ctx context.Context, r *pb.PublishRequest) (*pb.PublishResponse, error) {
+	st, pt := models.StatusPublished, models.Now()
+	if r.GetPublishTime() != nil {
+		if err := r.GetPublishTime().CheckValid(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if t := r.GetPublishTime().AsTime().Truncate(time.Millisecond); t.After(pt) {
+			st, pt = models.StatusDraft, t
+		}
+	}
+	m, err := s.setStatus(ctx, r.GetId(), st, pt)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PublishResponse{Article: m.ToPB()}, nil
+}
+
+// Unpublish turns an Article back into a draft and cancels scheduled publishing
+func (s *BlogServer) Unpublish(ctx context.Context, r *pb.UnpublishRequest) (*pb.UnpublishResponse, error) {
+	m, err := s.setStatus(ctx, r.GetId(), models.StatusDraft, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UnpublishResponse{Article: m.ToPB()}, nil
+}
+
+// Archive hides an Article from default listing keeping its publish time
+func (s *BlogServer) Archive(ctx context.Context, r *pb.ArchiveRequest) (*pb.ArchiveResponse, error) {
+	// archived drafts shouldn't stay scheduled, the repo decides by the stored status
+	m, err := s.r.Archive(ctx, r.GetId())
+	if err != nil {
+		log.Printf("Error archiving article: %v\n", err)
+		return nil, repoError(err, "id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.ArchiveResponse{Article: m.ToPB()}, nil
+}
+
+// setStatus changes status of an Article and converts errors into gRPC ones
+func (s *BlogServer) setStatus(ctx context.Context, id string, st models.Status, pt time.Time) (*models.Article, error) {
+	m, err := s.r.SetStatus(ctx, id, st, pt)
+	if err != nil {
+		log.Printf("Error setting article status to %v: %v\n", st, err)
+		return nil, repoError(err, "id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return m, nil
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func listTitles(t *testing.T, s *BlogServer, r *pb.ListRequest) []string {
+	ts := &testServer{articles: []*pb.Article{}}
+	if err := s.List(r, ts); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	titles := []string{}
+	for _, a := range ts.articles {
+		titles = append(titles, a.Title)
+	}
+	return titles
+}
+
+func TestPublish_lifecycle(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if c.Article.Status != pb.Article_DRAFT {
+		t.Fatalf("Expected a draft, got %v", c.Article.Status)
+	}
+	if titles := listTitles(t, s, &pb.ListRequest{}); len(titles) != 0 {
+		t.Fatalf("Drafts should not be listed by default: %v", titles)
+	}
+	drafts := &pb.ListRequest{Filter: &pb.ArticleFilter{Statuses: []pb.Article_Status{pb.Article_DRAFT}}}
+	if titles := listTitles(t, s, drafts); len(titles) != 1 {
+		t.Fatalf("Expected the draft to be listed: %v", titles)
+	}
+
+	p, err := s.Publish(context.Background(), &pb.PublishRequest{Id: c.Article.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if p.Article.Status != pb.Article_PUBLISHED || p.Article.PublishTime == nil {
+		t.Fatalf("Article was not published: %v", p.Article)
+	}
+	if titles := listTitles(t, s, &pb.ListRequest{}); len(titles) != 1 {
+		t.Fatalf("Expected published article to be listed: %v", titles)
+	}
+
+	a, err := s.Archive(context.Background(), &pb.ArchiveRequest{Id: c.Article.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if a.Article.Status != pb.Article_ARCHIVED || !a.Article.PublishTime.AsTime().Equal(p.Article.PublishTime.AsTime()) {
+		t.Fatalf("Article was not archived: %v", a.Article)
+	}
+	if titles := listTitles(t, s, &pb.ListRequest{}); len(titles) != 0 {
+		t.Fatalf("Archived articles should not be listed by default: %v", titles)
+	}
+
+	u, err := s.Unpublish(context.Background(), &pb.UnpublishRequest{Id: c.Article.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if u.Article.Status != pb.Article_DRAFT || u.Article.PublishTime != nil {
+		t.Fatalf("Article was not unpublished: %v", u.Article)
+	}
+}
+
+func TestPublish_scheduled(t *testing.T) {
+	r := repo.NewMapRepo(make(map[primitive.ObjectID]models.Article))
+	s := NewBlogServer(r, testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	at := time.Now().Add(time.Hour)
+	p, err := s.Publish(context.Background(), &pb.PublishRequest{Id: c.Article.Id, PublishTime: timestamppb.New(at)})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if p.Article.Status != pb.Article_DRAFT {
+		t.Fatalf("Article should stay a draft until publish time: %v", p.Article)
+	}
+
+	publishDue(context.Background(), r)
+	if titles := listTitles(t, s, &pb.ListRequest{}); len(titles) != 0 {
+		t.Fatalf("Article was published too early: %v", titles)
+	}
+
+	n, err := r.PublishScheduled(context.Background(), at.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("Expected 1 article to be published, got %v", n)
+	}
+	if titles := listTitles(t, s, &pb.ListRequest{}); len(titles) != 1 {
+		t.Fatalf("Scheduled article was not published: %v", titles)
+	}
+}
+
+func TestArchive_scheduled(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := s.Publish(context.Background(), &pb.PublishRequest{Id: c.Article.Id, PublishTime: timestamppb.New(time.Now().Add(time.Hour))}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	a, err := s.Archive(context.Background(), &pb.ArchiveRequest{Id: c.Article.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if a.Article.Status != pb.Article_ARCHIVED || a.Article.PublishTime != nil {
+		t.Fatalf("Archived draft should not stay scheduled: %v", a.Article)
+	}
+}
+
+func TestCreate_archived(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	res, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1", Status: pb.Article_ARCHIVED}})
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.InvalidArgument {
+		t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
+	}
+}
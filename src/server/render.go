@@ -0,0 +1,35 @@
+package server
+
+import (
+	"fmt"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/render"
+)
+
+// RenderCacheSize is how many rendered Articles BlogServer keeps in memory
+var RenderCacheSize = 1000
+
+// contentFormat returns the render format of the requested Article
+func contentFormat(a *pb.Article) (render.Format, error) {
+	f, ok := models.FormatFromPB(a.GetContentFormat())
+	if !ok {
+		return "", fmt.Errorf("Unknown content format: %v", a.GetContentFormat())
+	}
+	return f, nil
+}
+
+// withHTML fills rendered HTML of the Article
+// Every change bumps the version, so ID and version identify the content
+func (s *BlogServer) withHTML(a *pb.Article) *pb.Article {
+	f, _ := contentFormat(a)
+	h, err := s.html.HTML(fmt.Sprintf("%v:%v", a.GetId(), a.GetVersion()), a.GetContent(), f)
+	if err != nil {
+		log.Printf("Error rendering article %v: %v\n", a.GetId(), err)
+		return a
+	}
+	a.RenderedHtml = h
+	return a
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/render"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func newRenderServer() *BlogServer {
+	return NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+}
+
+func createRendered(t *testing.T, s *BlogServer, content string, f pb.Article_ContentFormat) *pb.Article {
+	t.Helper()
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1", Content: content, ContentFormat: f}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	res, err := s.Read(context.Background(), &pb.ReadRequest{Id: c.Article.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	return res.Article
+}
+
+func TestRender_markdown(t *testing.T) {
+	s := newRenderServer()
+	a := createRendered(t, s, "# Title\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n```go\nfmt.Println(\"<hi>\")\n```\n", pb.Article_MARKDOWN)
+
+	if a.ContentFormat != pb.Article_MARKDOWN {
+		t.Fatalf("Expected markdown format, got %v", a.ContentFormat)
+	}
+	for _, want := range []string{"<h1>Title</h1>", "<table>", "<td>1</td>", `<code class="language-go">`, "&lt;hi&gt;"} {
+		if !strings.Contains(a.RenderedHtml, want) {
+			t.Fatalf("Expected %q in rendered HTML: %v", want, a.RenderedHtml)
+		}
+	}
+}
+
+func TestRender_sanitizes(t *testing.T) {
+	s := newRenderServer()
+	a := createRendered(t, s, `<p onclick="x()">Hi <script>alert(1)</script><img src="x.png" onerror="alert(2)"><a href="javascript:alert(3)">link</a></p>`, pb.Article_HTML)
+
+	for _, bad := range []string{"script", "alert", "onclick", "onerror", "javascript"} {
+		if strings.Contains(a.RenderedHtml, bad) {
+			t.Fatalf("Rendered HTML kept %q: %v", bad, a.RenderedHtml)
+		}
+	}
+	if !strings.Contains(a.RenderedHtml, `<img src="x.png">`) || !strings.Contains(a.RenderedHtml, ">link</a>") {
+		t.Fatalf("Safe markup was dropped: %v", a.RenderedHtml)
+	}
+
+	m := createRendered(t, s, "Hi <script>alert(1)</script>", pb.Article_MARKDOWN)
+	if strings.Contains(m.RenderedHtml, "<script") {
+		t.Fatalf("Raw HTML in markdown was kept: %v", m.RenderedHtml)
+	}
+}
+
+func TestRender_plain(t *testing.T) {
+	s := newRenderServer()
+	a := createRendered(t, s, "1 < 2\nand <b>\n\nnext", pb.Article_CONTENT_FORMAT_UNSPECIFIED)
+
+	if a.ContentFormat != pb.Article_PLAIN {
+		t.Fatalf("Expected plain format, got %v", a.ContentFormat)
+	}
+	if a.RenderedHtml != "<p>1 &lt; 2<br>\nand &lt;b&gt;</p>\n<p>next</p>\n" {
+		t.Fatalf("Unexpected rendered HTML: %q", a.RenderedHtml)
+	}
+}
+
+func TestRender_unknown_format(t *testing.T) {
+	s := newRenderServer()
+	_, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1", ContentFormat: 42}})
+
+	if e, ok := status.FromError(err); !ok || e.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestRender_cached_per_version(t *testing.T) {
+	s := newRenderServer()
+	a := createRendered(t, s, "*one*", pb.Article_MARKDOWN)
+	if _, err := s.Read(context.Background(), &pb.ReadRequest{Id: a.Id}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if s.html.Len() != 1 {
+		t.Fatalf("Expected 1 cached render, got %v", s.html.Len())
+	}
+
+	_, err := s.Update(context.Background(), &pb.UpdateRequest{
+		Article:    &pb.Article{Id: a.Id, Content: "*one*", ContentFormat: pb.Article_PLAIN},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{models.FieldContentFormat}},
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	res, err := s.Read(context.Background(), &pb.ReadRequest{Id: a.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res.Article.RenderedHtml != "<p>*one*</p>\n" {
+		t.Fatalf("Stale rendered HTML after update: %q", res.Article.RenderedHtml)
+	}
+	if s.html.Len() != 2 {
+		t.Fatalf("Expected 2 cached renders, got %v", s.html.Len())
+	}
+}
+
+func TestRender_cache_evicts(t *testing.T) {
+	c := render.NewCache(1)
+	if _, err := c.HTML("a", "a", render.FormatPlain); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := c.HTML("b", "b", render.FormatPlain); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("Expected 1 cached render, got %v", c.Len())
+	}
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListRevisions returns previous versions of an Article, newest first
+func (s *BlogServer) ListRevisions(ctx context.Context, r *pb.ListRevisionsRequest) (*pb.ListRevisionsResponse, error) {
+	revs, err := s.r.ListRevisions(ctx, r.GetArticleId())
+	if err != nil {
+		log.Printf("Error listing revisions: %v\n", err)
+		return nil, repoError(err, "article_id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	res := &pb.ListRevisionsResponse{Revisions: make([]*pb.Revision, 0, len(revs))}
+	for _, rev := range revs {
+		res.Revisions = append(res.Revisions, rev.ToPB())
+	}
+	return res, nil
+}
+
+// GetRevision returns one previous version of an Article
+func (s *BlogServer) GetRevision(ctx context.Context, r *pb.GetRevisionRequest) (*pb.GetRevisionResponse, error) {
+	rev, err := s.r.GetRevision(ctx, r.GetArticleId(), r.GetRevisionId())
+	if err != nil {
+		log.Printf("Error getting revision: %v\n", err)
+		return nil, repoError(err, "article_id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.GetRevisionResponse{Revision: rev.ToPB()}, nil
+}
+
+// RestoreRevision updates an Article with the content of its revision
+// The current version is kept as a new revision, so restoring can be undone
+// The revision is checked the way Update checks Articles, rules and Authors may have changed since it was made
+func (s *BlogServer) RestoreRevision(ctx context.Context, r *pb.RestoreRevisionRequest) (*pb.RestoreRevisionResponse, error) {
+	rev, err := s.r.GetRevision(ctx, r.GetArticleId(), r.GetRevisionId())
+	if err != nil {
+		log.Printf("Error restoring revision: %v\n", err)
+		return nil, repoError(err, "article_id")
+	}
+	a := rev.Article
+	if err := validate(&a, models.AllFields, "revision.article"); err != nil {
+		return nil, err
+	}
+	if err := s.checkAuthor(ctx, a.AuthorID); err != nil {
+		return nil, err
+	}
+	a.Version = r.GetExpectedVersion()
+	m, err := s.r.UpdateArticle(ctx, &a, models.AllFields)
+	if err != nil {
+		log.Printf("Error restoring revision: %v\n", err)
+		return nil, repoError(err, "article_id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.RestoreRevisionResponse{Article: m.ToPB()}, nil
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestRevisions(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "v1", Content: "first"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	id := c.Article.Id
+	for _, title := range []string{"v2", "v3"} {
+		_, err := s.Update(context.Background(), &pb.UpdateRequest{
+			Article:    &pb.Article{Id: id, AuthorId: testAuthor.Hex(), Title: title},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+		})
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+	}
+
+	l, err := s.ListRevisions(context.Background(), &pb.ListRevisionsRequest{ArticleId: id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(l.Revisions) != 2 || l.Revisions[0].Article.Title != "v2" || l.Revisions[1].Article.Title != "v1" {
+		t.Fatalf("Wrong revisions: %v", l.Revisions)
+	}
+	first := l.Revisions[1]
+
+	g, err := s.GetRevision(context.Background(), &pb.GetRevisionRequest{ArticleId: id, RevisionId: first.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if g.Revision.Article.Content != "first" {
+		t.Fatalf("Wrong revision: %v", g.Revision)
+	}
+
+	rd, err := s.Read(context.Background(), &pb.ReadRequest{Id: id, RevisionId: first.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if rd.Article.Title != "v1" {
+		t.Fatalf("Expected article as of the first revision, got %v", rd.Article)
+	}
+
+	rs, err := s.RestoreRevision(context.Background(), &pb.RestoreRevisionRequest{ArticleId: id, RevisionId: first.Id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if rs.Article.Title != "v1" || rs.Article.Content != "first" {
+		t.Fatalf("Revision was not restored: %v", rs.Article)
+	}
+	l, err = s.ListRevisions(context.Background(), &pb.ListRevisionsRequest{ArticleId: id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(l.Revisions) != 3 || l.Revisions[0].Article.Title != "v3" {
+		t.Fatalf("Restoring should keep the current version: %v", l.Revisions)
+	}
+}
+
+func TestRestoreRevision_checked(t *testing.T) {
+	au := testAuthors()
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), au)
+	bob, err := au.AddAuthor(context.Background(), &models.Author{DisplayName: "Bob"})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: bob, Title: "v1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	id := c.Article.Id
+	_, err = s.Update(context.Background(), &pb.UpdateRequest{
+		Article:    &pb.Article{Id: id, AuthorId: testAuthor.Hex()},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"author_id"}},
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := au.DeleteAuthor(context.Background(), bob); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	l, err := s.ListRevisions(context.Background(), &pb.ListRevisionsRequest{ArticleId: id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	_, err = s.RestoreRevision(context.Background(), &pb.RestoreRevisionRequest{ArticleId: id, RevisionId: l.Revisions[0].Id})
+	if se, ok := status.FromError(err); !ok || se.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected %v for a revision of a deleted author, got %v", codes.InvalidArgument.String(), err)
+	}
+	rd, err := s.Read(context.Background(), &pb.ReadRequest{Id: id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if rd.Article.AuthorId != testAuthor.Hex() {
+		t.Fatalf("Article should not be restored: %v", rd.Article)
+	}
+}
+
+func TestGetRevision_missing(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	res, err := s.GetRevision(context.Background(), &pb.GetRevisionRequest{ArticleId: primitive.NewObjectID().Hex(), RevisionId: primitive.NewObjectID().Hex()})
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if err == nil {
+		t.Fatal("Expected error for missing revision")
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+)
+
+// PublishInterval controls how often scheduled drafts are checked
+var PublishInterval time.Duration = time.Duration(time.Minute)
+
+// PurgeInterval controls how often trash is checked for Articles to purge
+var PurgeInterval time.Duration = time.Duration(time.Hour)
+
+// TrashRetention controls how long deleted Articles stay in trash before being purged
+var TrashRetention time.Duration = time.Duration(30 * 24 * time.Hour)
+
+// RunScheduler publishes scheduled drafts every PublishInterval until the context is done
+func RunScheduler(ctx context.Context, r repo.ArticleRepo) {
+	runEvery(ctx, PublishInterval, func(ctx context.Context) {
+		publishDue(ctx, r)
+	})
+}
+
+// RunPurger removes Articles which stayed in trash longer than TrashRetention together with their Comments
+// every PurgeInterval until the context is done
+func RunPurger(ctx context.Context, r repo.ArticleRepo, c repo.CommentRepo) {
+	// purged Articles which Comments failed to be deleted are retried on the next run
+	var pending []string
+	runEvery(ctx, PurgeInterval, func(ctx context.Context) {
+		pending = purgeTrash(ctx, r, c, pending)
+	})
+}
+
+// runEvery calls f with a context limited by the interval until the parent context is done
+func runEvery(ctx context.Context, interval time.Duration, f func(context.Context)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			tctx, cancel := context.WithTimeout(ctx, interval)
+			f(tctx)
+			cancel()
+		}
+	}
+}
+
+// publishDue publishes drafts which publish time has passed
+func publishDue(ctx context.Context, r repo.ArticleRepo) {
+	n, err := r.PublishScheduled(ctx, models.Now())
+	if err != nil {
+		log.Printf("Error publishing scheduled articles: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Published %v scheduled articles", n)
+	}
+}
+
+// purgeTrash removes Articles deleted more than TrashRetention ago and their Comments
+// Comments of pending Articles purged before are deleted too
+// returns IDs of purged Articles which Comments are still to be deleted
+func purgeTrash(ctx context.Context, r repo.ArticleRepo, c repo.CommentRepo, pending []string) []string {
+	// removed Articles are returned along with errors about their revisions, their Comments still have to go
+	ids, err := r.PurgeDeleted(ctx, models.Now().Add(-TrashRetention))
+	if err != nil {
+		log.Printf("Error purging deleted articles: %v", err)
+	}
+	if len(ids) > 0 {
+		log.Printf("Purged %v deleted articles", len(ids))
+	}
+	ids = append(pending, ids...)
+	if len(ids) == 0 {
+		return nil
+	}
+	n, err := c.DeleteArticleComments(ctx, ids)
+	if err != nil {
+		log.Printf("Error deleting comments of purged articles: %v", err)
+		return ids
+	}
+	log.Printf("Deleted %v comments of purged articles", n)
+	return nil
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/search"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+	// snippetWords is the length of content snippet in words
+	snippetWords = 30
+)
+
+// Search finds Articles by words in title and content ordered by relevance
+func (s *BlogServer) Search(ctx context.Context, r *pb.SearchRequest) (*pb.SearchResponse, error) {
+	terms := search.Terms(r.GetQuery())
+	if len(terms) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Search query has no words")
+	}
+	limit := int64(r.GetLimit())
+	if limit < 0 || limit > maxSearchLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "Limit should be between 0 and %v", maxSearchLimit)
+	}
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	found, err := s.r.SearchArticles(ctx, terms, limit)
+	if err != nil {
+		log.Printf("Error searching articles: %v\n", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	res := &pb.SearchResponse{Results: make([]*pb.SearchResult, 0, len(found))}
+	for _, f := range found {
+		res.Results = append(res.Results, &pb.SearchResult{
+			Article:        f.Article.ToPB(),
+			Score:          f.Score,
+			TitleSnippet:   search.Highlight(f.Article.Title, terms),
+			ContentSnippet: search.Snippet(f.Article.Content, terms, snippetWords),
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSearch_ranking(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article, 3)
+	for i, a := range []models.Article{
+		{Title: "Cooking pasta", Content: "Boil water and add salt"},
+		{Title: "Notes", Content: "Some words about Gophers and how gophers dig"},
+		{Title: "Gophers", Content: "All about them"},
+	} {
+		oid, _ := primitive.ObjectIDFromHex(hex.EncodeToString([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, byte(i + 1)}))
+		a.ID = oid
+		m[oid] = a
+	}
+
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+
+	res, err := s.Search(context.Background(), &pb.SearchRequest{Query: "gophers"})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(res.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %v", res.Results)
+	}
+	if res.Results[0].Article.Title != "Gophers" || res.Results[1].Article.Title != "Notes" {
+		t.Fatalf("Wrong order of results: %v", res.Results)
+	}
+	if res.Results[0].TitleSnippet != "<em>Gophers</em>" {
+		t.Errorf("Wrong title snippet: %v", res.Results[0].TitleSnippet)
+	}
+	if !strings.Contains(res.Results[1].ContentSnippet, "about <em>Gophers</em> and how <em>gophers</em> dig") {
+		t.Errorf("Wrong content snippet: %v", res.Results[1].ContentSnippet)
+	}
+}
+
+func TestSearch_index_updated(t *testing.T) {
+	r := repo.NewMapRepo(make(map[primitive.ObjectID]models.Article))
+	s := NewBlogServer(r, testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Old title", Status: pb.Article_PUBLISHED}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	c.Article.Title = "New title"
+	if _, err := s.Update(context.Background(), &pb.UpdateRequest{Article: c.Article}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	res, err := s.Search(context.Background(), &pb.SearchRequest{Query: "old"})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(res.Results) != 0 {
+		t.Fatalf("Expected no results, got %v", res.Results)
+	}
+	res, err = s.Search(context.Background(), &pb.SearchRequest{Query: "new"})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(res.Results) != 1 {
+		t.Fatalf("Expected 1 result, got %v", res.Results)
+	}
+}
+
+func TestSearch_empty_query(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	res, err := s.Search(context.Background(), &pb.SearchRequest{Query: " ?! "})
+	if res != nil {
+		t.Fatalf("Got result: %v", res)
+	}
+	if se, ok := status.FromError(err); !ok {
+		t.Error("Could not initialize status from error")
+	} else if se.Code() != codes.InvalidArgument {
+		t.Errorf("Error status code is not %v, it's %v", codes.InvalidArgument.String(), se.Code().String())
+	}
+}
+
+func TestSearch_snippets_escaped(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article, 1)
+	oid := primitive.NewObjectID()
+	m[oid] = models.Article{ID: oid, Title: "<b>World</b> news", Content: "hello <script>alert(1)</script> world"}
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+
+	res, err := s.Search(context.Background(), &pb.SearchRequest{Query: "world"})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(res.Results) != 1 {
+		t.Fatalf("Expected 1 result, got %v", res.Results)
+	}
+	if got := res.Results[0].TitleSnippet; got != "&lt;b&gt;<em>World</em>&lt;/b&gt; news" {
+		t.Errorf("Wrong title snippet: %v", got)
+	}
+	if got := res.Results[0].ContentSnippet; got != "hello &lt;script&gt;alert(1)&lt;/script&gt; <em>world</em>" {
+		t.Errorf("Wrong content snippet: %v", got)
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/repo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetStats returns the number of live Articles and their words in total and per author, tag and month
+func (s *BlogServer) GetStats(ctx context.Context, r *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
+	st, err := s.r.GetStats(ctx)
+	if err != nil {
+		log.Printf("Error getting stats: %v\n", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.GetStatsResponse{
+		ArticleCount: st.Articles,
+		WordCount:    st.Words,
+		Authors:      statsBuckets(st.ByAuthor),
+		Tags:         statsBuckets(st.ByTag),
+		Months:       statsBuckets(st.ByMonth),
+	}, nil
+}
+
+func statsBuckets(bs []repo.StatsBucket) []*pb.StatsBucket {
+	res := make([]*pb.StatsBucket, 0, len(bs))
+	for _, b := range bs {
+		res = append(res, &pb.StatsBucket{Key: b.Key, ArticleCount: b.Articles, WordCount: b.Words})
+	}
+	return res
+}
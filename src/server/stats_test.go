@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetStats(t *testing.T) {
+	jan := time.Date(2021, 1, 31, 23, 0, 0, 0, time.UTC)
+	feb := time.Date(2021, 2, 1, 1, 0, 0, 0, time.UTC)
+	m := make(map[primitive.ObjectID]models.Article)
+	for _, a := range []models.Article{
+		{AuthorID: "bob", Tags: []string{"go", "grpc"}, WordCount: 100, CreateTime: jan},
+		{AuthorID: "bob", Tags: []string{"go"}, WordCount: 50, CreateTime: feb},
+		{AuthorID: "ann", Tags: []string{"grpc"}, WordCount: 10, CreateTime: feb},
+		{AuthorID: "ann", Tags: []string{"go"}, WordCount: 1000, CreateTime: feb, DeleteTime: feb},
+	} {
+		a.ID = primitive.NewObjectID()
+		m[a.ID] = a
+	}
+	s := BlogServer{r: repo.NewMapRepo(m)}
+
+	res, err := s.GetStats(context.Background(), &pb.GetStatsRequest{})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res.ArticleCount != 3 || res.WordCount != 160 {
+		t.Fatalf("Expected 3 articles of 160 words, got %v of %v", res.ArticleCount, res.WordCount)
+	}
+	check := func(name string, got []*pb.StatsBucket, want []*pb.StatsBucket) {
+		if len(got) != len(want) {
+			t.Fatalf("Expected %v %v, got %v", len(want), name, got)
+		}
+		for i := range want {
+			if got[i].Key != want[i].Key || got[i].ArticleCount != want[i].ArticleCount || got[i].WordCount != want[i].WordCount {
+				t.Fatalf("Expected %v %v, got %v", name, want, got)
+			}
+		}
+	}
+	check("authors", res.Authors, []*pb.StatsBucket{{Key: "bob", ArticleCount: 2, WordCount: 150}, {Key: "ann", ArticleCount: 1, WordCount: 10}})
+	check("tags", res.Tags, []*pb.StatsBucket{{Key: "go", ArticleCount: 2, WordCount: 150}, {Key: "grpc", ArticleCount: 2, WordCount: 110}})
+	check("months", res.Months, []*pb.StatsBucket{{Key: "2021-01", ArticleCount: 1, WordCount: 100}, {Key: "2021-02", ArticleCount: 2, WordCount: 60}})
+}
+
+type statsErrorRepo struct {
+	repo.MapArticleRepo
+}
+
+func (r *statsErrorRepo) GetStats(ctx context.Context) (*repo.Stats, error) {
+	return nil, errors.New("aggregation failed")
+}
+
+func TestGetStats_error(t *testing.T) {
+	s := BlogServer{r: &statsErrorRepo{}}
+
+	_, err := s.GetStats(context.Background(), &pb.GetStatsRequest{})
+	if e, ok := status.FromError(err); !ok || e.Code() != codes.Internal {
+		t.Fatalf("Expected Internal error, got %v", err)
+	}
+}
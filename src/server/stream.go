@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// page describes how List like RPCs stream the items a repo fills the channel with
+type page struct {
+	// limit is the page size plus the lookahead item, zero means no limit
+	limit int64
+	// fill runs the repo call which fills the channel until stop is closed
+	fill func(stop <-chan struct{}) error
+	// recv reads the next item from the channel, it's false once the channel is closed
+	recv func() (interface{}, bool)
+	// token makes the token of the next page out of the last item of the page
+	token func(interface{}) string
+	// send sends an item with the token of the next page, which is empty unless the item ends the page
+	send func(v interface{}, next string) error
+}
+
+// streamPage sends a page of items to the stream until ctx is done
+func streamPage(ctx context.Context, p page) error {
+	stop := make(chan struct{})
+	// buffered so the sender doesn't block when fill fails and nobody reads it
+	e := make(chan error, 1)
+	go func() {
+		defer close(e)
+		send := func(v interface{}, next string) bool {
+			if ctx.Err() == context.DeadlineExceeded {
+				interruptPage("Exceeded deadline", p.recv, stop, e, codes.DeadlineExceeded)
+				return false
+			}
+			if err := p.send(v, next); err != nil {
+				interruptPage(fmt.Sprintf("Got error while sending: %v", err), p.recv, stop, e, codes.Internal)
+				return false
+			}
+			return true
+		}
+		// one item is held back to know whether it's the last one of the page
+		var last interface{}
+		n := int64(0)
+		for {
+			v, ok := p.recv()
+			if !ok {
+				break
+			}
+			n++
+			if last != nil {
+				next := ""
+				if p.limit > 0 && n == p.limit {
+					next = p.token(last)
+				}
+				if !send(last, next) {
+					return
+				}
+			}
+			last = v
+			if p.limit > 0 && n == p.limit {
+				// the lookahead item belongs to the next page
+				last = nil
+			}
+		}
+		if last != nil && !send(last, "") {
+			return
+		}
+		// let the repo know the reading is over
+		close(stop)
+		e <- nil
+	}()
+	err := p.fill(stop)
+	if err != nil {
+		log.Printf("Error when filling out channel: %v", err)
+		return status.Error(codes.Internal, internalError)
+	}
+
+	return <-e
+}
+
+// interruptPage used to interrupt fetching of new items
+func interruptPage(msg string, recv func() (interface{}, bool), stop chan<- struct{}, e chan<- error, code codes.Code) {
+	// dump last value from the channel
+	// Otherwise we're stuck because message was already sent and waiting for next iteration to read it
+	// before "interrupt" signal can be read
+	recv()
+	close(stop)
+	log.Print(msg)
+	e <- status.Errorf(code, internalError)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestSummary_create(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	res, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{
+		AuthorId:      testAuthor.Hex(),
+		Title:         "Book1",
+		Content:       "# Intro\n\nOnce **upon** a time. " + strings.Repeat("word ", 400),
+		ContentFormat: pb.Article_MARKDOWN,
+	}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res.Article.Excerpt != "Intro Once upon a time." {
+		t.Fatalf("Unexpected excerpt: %q", res.Article.Excerpt)
+	}
+	if res.Article.WordCount != 405 {
+		t.Fatalf("Expected 405 words, got %v", res.Article.WordCount)
+	}
+	if res.Article.ReadingMinutes != 3 {
+		t.Fatalf("Expected 3 minutes of reading, got %v", res.Article.ReadingMinutes)
+	}
+}
+
+func TestSummary_excerpt_without_sentence_end(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	res, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1", Content: strings.Repeat("abc ", 100)}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	want := strings.Repeat("abc ", 49) + "abc…"
+	if res.Article.Excerpt != want {
+		t.Fatalf("Expected excerpt %q, got %q", want, res.Article.Excerpt)
+	}
+}
+
+func TestSummary_update(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1", Content: "One two."}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	res, err := s.Update(context.Background(), &pb.UpdateRequest{
+		Article:    &pb.Article{Id: c.Article.Id, Content: "One two three."},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{models.FieldContent}},
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res.Article.Excerpt != "One two three." || res.Article.WordCount != 3 || res.Article.ReadingMinutes != 1 {
+		t.Fatalf("Summary was not updated: %v", res.Article)
+	}
+}
+
+func TestSummary_list(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+	_, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1", Content: "One two.", Status: pb.Article_PUBLISHED}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	ts := &testServer{articles: []*pb.Article{}}
+	if err := s.List(&pb.ListRequest{SummaryOnly: true}, ts); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	if len(ts.articles) != 1 {
+		t.Fatalf("Expected 1 article, got %v", ts.articles)
+	}
+	a := ts.articles[0]
+	if a.Content != "" || a.RenderedHtml != "" {
+		t.Fatalf("Expected content to be left out: %v", a)
+	}
+	if a.Excerpt != "One two." || a.WordCount != 2 {
+		t.Fatalf("Expected summary fields: %v", a)
+	}
+
+	full := &testServer{articles: []*pb.Article{}}
+	if err := s.List(&pb.ListRequest{}, full); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	if full.articles[0].Content != "One two." || full.articles[0].RenderedHtml == "" {
+		t.Fatalf("Expected full article: %v", full.articles[0])
+	}
+}
+
+func TestSummary_excerpt_multibyte(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	res, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1", Content: strings.Repeat("Жили-были. ", 30)}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	want := strings.TrimSpace(strings.Repeat("Жили-были. ", 18))
+	if res.Article.Excerpt != want {
+		t.Fatalf("Expected excerpt %q, got %q", want, res.Article.Excerpt)
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListTags returns all tags with the number of Articles having them
+func (s *BlogServer) ListTags(ctx context.Context, r *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	tags, err := s.r.ListTags(ctx)
+	if err != nil {
+		log.Printf("Error listing tags: %v\n", err)
+		return nil, status.Error(codes.Internal, internalError)
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	res := &pb.ListTagsResponse{Tags: make([]*pb.TagCount, 0, len(tags))}
+	for _, t := range tags {
+		res.Tags = append(res.Tags, &pb.TagCount{Tag: t.Tag, ArticleCount: t.Count})
+	}
+	return res, nil
+}
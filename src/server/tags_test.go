@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func TestListTags(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	var last *pb.Article
+	for _, tags := range [][]string{{"Go", " go ", "gRPC"}, {"go"}, {"Mongo", "GO"}} {
+		res, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book", Tags: tags, Status: pb.Article_PUBLISHED}})
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+		last = res.Article
+	}
+	// unpublished Articles are not counted
+	if _, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Draft", Tags: []string{"go", "draft"}}}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(last.Tags) != 2 || last.Tags[0] != "mongo" || last.Tags[1] != "go" {
+		t.Fatalf("Tags were not normalized: %v", last.Tags)
+	}
+
+	res, err := s.ListTags(context.Background(), &pb.ListTagsRequest{})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	expected := []*pb.TagCount{{Tag: "go", ArticleCount: 3}, {Tag: "grpc", ArticleCount: 1}, {Tag: "mongo", ArticleCount: 1}}
+	if len(res.Tags) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, res.Tags)
+	}
+	for i, tc := range res.Tags {
+		if tc.Tag != expected[i].Tag || tc.ArticleCount != expected[i].ArticleCount {
+			t.Fatalf("Expected %v, got %v", expected, res.Tags)
+		}
+	}
+
+	last.Tags = []string{"grpc"}
+	_, err = s.Update(context.Background(), &pb.UpdateRequest{Article: last, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"tags"}}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	res, err = s.ListTags(context.Background(), &pb.ListTagsRequest{})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(res.Tags) != 2 || res.Tags[0].Tag != "go" || res.Tags[0].ArticleCount != 2 || res.Tags[1].ArticleCount != 2 {
+		t.Fatalf("Tag index was not updated: %v", res.Tags)
+	}
+}
+
+func TestList_filter_tags(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	for i, tags := range [][]string{{"go", "grpc"}, {"go"}, {"grpc"}} {
+		_, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: string(rune('A' + i)), Tags: tags, Status: pb.Article_PUBLISHED}})
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+	}
+
+	ts := &testServer{articles: []*pb.Article{}}
+	err := s.List(&pb.ListRequest{Filter: &pb.ArticleFilter{Tags: []string{"GRPC", "go"}}}, ts)
+	if err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	if len(ts.articles) != 1 || ts.articles[0].Title != "A" {
+		t.Fatalf("Expected only article A, got %v", ts.articles)
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/repo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Undelete restores an Article from trash
+func (s *BlogServer) Undelete(ctx context.Context, r *pb.UndeleteRequest) (*pb.UndeleteResponse, error) {
+	m, err := s.r.UndeleteArticle(ctx, r.GetId())
+	if err != nil {
+		log.Printf("Error undeleting article: %v\n", err)
+		return nil, repoError(err, "id")
+	}
+	if ctx.Err() == context.Canceled {
+		return nil, status.Error(codes.Canceled, requestCancelled)
+	}
+	return &pb.UndeleteResponse{Article: m.ToPB()}, nil
+}
+
+// ListDeleted streams Articles in trash page by page
+func (s *BlogServer) ListDeleted(r *pb.ListDeletedRequest, stream pb.Blog_ListDeletedServer) error {
+	q := repo.ArticleQuery{Filter: repo.ArticleFilter{Deleted: true}}
+	if err := setPage(&q, r.GetPageSize(), r.GetPageToken()); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.streamArticles(q, false, stream)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTrash_lifecycle(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	r := repo.NewMapRepo(m)
+	s := NewBlogServer(r, testAuthors())
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1", Status: pb.Article_PUBLISHED}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	id := c.Article.Id
+	if _, err := s.Delete(context.Background(), &pb.DeleteRequest{Id: id}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	if _, err := s.Read(context.Background(), &pb.ReadRequest{Id: id}); err == nil {
+		t.Fatal("Deleted article should not be readable")
+	}
+	if titles := listTitles(t, s, &pb.ListRequest{}); len(titles) != 0 {
+		t.Fatalf("Deleted articles should not be listed: %v", titles)
+	}
+	ts := &testServer{articles: []*pb.Article{}}
+	if err := s.ListDeleted(&pb.ListDeletedRequest{}, ts); err != nil {
+		t.Fatalf("Got error back: %v", err)
+	}
+	if len(ts.articles) != 1 || ts.articles[0].Id != id || ts.articles[0].DeleteTime == nil {
+		t.Fatalf("Expected the article in trash, got %v", ts.articles)
+	}
+
+	u, err := s.Undelete(context.Background(), &pb.UndeleteRequest{Id: id})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if u.Article.DeleteTime != nil {
+		t.Fatalf("Article is still in trash: %v", u.Article)
+	}
+	if titles := listTitles(t, s, &pb.ListRequest{}); len(titles) != 1 {
+		t.Fatalf("Restored article should be listed: %v", titles)
+	}
+	if _, err := s.Undelete(context.Background(), &pb.UndeleteRequest{Id: id}); err == nil {
+		t.Fatal("Live article should not be undeleted")
+	}
+}
+
+func TestTrash_purge(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	r := repo.NewMapRepo(m)
+	s := NewBlogServer(r, testAuthors())
+
+	ids := []string{}
+	for _, title := range []string{"Book1", "Book2"} {
+		c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: title}})
+		if err != nil {
+			t.Fatalf("Got error: %v", err)
+		}
+		ids = append(ids, c.Article.Id)
+	}
+	if _, err := s.Delete(context.Background(), &pb.DeleteRequest{Id: ids[0]}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	purgeTrash(context.Background(), r, repo.NewMapCommentRepo(make(map[primitive.ObjectID]models.Comment)), nil)
+	if len(m) != 2 {
+		t.Fatal("Recently deleted article should stay in trash")
+	}
+
+	purged, err := r.PurgeDeleted(context.Background(), models.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if len(purged) != 1 || purged[0] != ids[0] || len(m) != 1 {
+		t.Fatalf("Expected the first article purged, got %v", purged)
+	}
+	oid, _ := primitive.ObjectIDFromHex(ids[1])
+	if _, ok := m[oid]; !ok {
+		t.Fatal("Live article should not be purged")
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"strings"
+
+	"example.com/grpc/blog/src/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validate checks the fields of the Article against models.ArticleRules
+// field is the path of the Article in the request, violated fields are reported under it
+// Tags are checked as requested, so violations point at the requested items, and normalized once valid
+func validate(m *models.Article, fields []string, field string) error {
+	vs := m.Validate(fields)
+	if len(vs) == 0 {
+		m.Tags = models.NormalizeTags(m.Tags)
+		return nil
+	}
+	br := &errdetails.BadRequest{FieldViolations: make([]*errdetails.BadRequest_FieldViolation, 0, len(vs))}
+	msgs := make([]string, 0, len(vs))
+	for _, v := range vs {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field + "." + v.Field,
+			Description: v.Description,
+		})
+		msgs = append(msgs, v.Field+": "+v.Description)
+	}
+	// clients which don't read details still see every violation
+	return withDetails(status.New(codes.InvalidArgument, "Invalid article: "+strings.Join(msgs, "; ")), br)
+}
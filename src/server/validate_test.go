@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// violations returns the fields of BadRequest details of an InvalidArgument error
+func violations(t *testing.T, err error) []string {
+	se, ok := status.FromError(err)
+	if !ok || se.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected %v, got %v", codes.InvalidArgument.String(), err)
+	}
+	var fields []string
+	for _, d := range se.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	return fields
+}
+
+func TestValidate_create(t *testing.T) {
+	m := make(map[primitive.ObjectID]models.Article)
+	s := NewBlogServer(repo.NewMapRepo(m), testAuthors())
+	author := testAuthor.Hex()
+	// the limit counts tags as requested, duplicates included
+	tags := make([]string, models.MaxTags+1)
+	for i := range tags {
+		tags[i] = "go"
+	}
+
+	tests := []struct {
+		name    string
+		article *pb.Article
+		fields  []string
+	}{
+		{"empty", &pb.Article{}, []string{"article.author_id", "article.title"}},
+		{"long title", &pb.Article{AuthorId: author, Title: strings.Repeat("a", models.MaxTitleLength+1)}, []string{"article.title"}},
+		{"long content", &pb.Article{AuthorId: author, Title: "t", Content: strings.Repeat("a", models.MaxContentLength+1)}, []string{"article.content"}},
+		{"control characters", &pb.Article{AuthorId: author, Title: "a\x00b", Content: "line\x07"}, []string{"article.title", "article.content"}},
+		{"invalid UTF-8", &pb.Article{AuthorId: author, Title: "\xff"}, []string{"article.title"}},
+		{"author ID", &pb.Article{AuthorId: "Bob", Title: "t"}, []string{"article.author_id"}},
+		{"short author ID", &pb.Article{AuthorId: "abc", Title: "t"}, []string{"article.author_id"}},
+		{"line break in title", &pb.Article{AuthorId: author, Title: "a\nb"}, []string{"article.title"}},
+		{"non-character", &pb.Article{AuthorId: author, Title: "a\uFFFE"}, []string{"article.title"}},
+		{"tags", &pb.Article{AuthorId: author, Title: "t", Tags: []string{"go", "c++", strings.Repeat("a", models.MaxTagLength+1)}}, []string{"article.tags[1]", "article.tags[2]"}},
+		{"tags as requested", &pb.Article{AuthorId: author, Title: "t", Tags: []string{"", "c++"}}, []string{"article.tags[1]"}},
+		{"too many tags", &pb.Article{AuthorId: author, Title: "t", Tags: tags}, []string{"article.tags"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Create(context.Background(), &pb.CreateRequest{Article: tt.article})
+			got := violations(t, err)
+			if strings.Join(got, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("Expected violations of %v, got %v", tt.fields, got)
+			}
+		})
+	}
+	if len(m) != 0 {
+		t.Errorf("Invalid articles were stored: %v", m)
+	}
+}
+
+func TestValidate_text(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	// NBSP, em space, soft hyphen and ZWJ are regular parts of text
+	title := "Go\u00a0in\u2003prac\u00adtice \U0001F469\u200d\U0001F4BB"
+	res, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{
+		AuthorId: testAuthor.Hex(),
+		Title:    title,
+		Content:  "Line one\r\n\tline two",
+		Tags:     []string{"Go", "go"},
+	}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if res.Article.Title != title || len(res.Article.Tags) != 1 || res.Article.Tags[0] != "go" {
+		t.Fatalf("Got wrong article: %v", res.Article)
+	}
+}
+
+func TestValidate_update_masked_fields(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	// fields out of the mask are not validated
+	u, err := s.Update(context.Background(), &pb.UpdateRequest{
+		Article:    &pb.Article{Id: c.Article.Id, Content: "Once upon a time"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"content"}},
+	})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if u.Article.Title != "Book1" || u.Article.Content != "Once upon a time" {
+		t.Fatalf("Got wrong article: %v", u.Article)
+	}
+
+	_, err = s.Update(context.Background(), &pb.UpdateRequest{
+		Article:    &pb.Article{Id: c.Article.Id},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title", "content"}},
+	})
+	if got := violations(t, err); len(got) != 1 || got[0] != "article.title" {
+		t.Errorf("Expected title violation, got %v", got)
+	}
+}
+
+func TestValidate_batch(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+
+	res, err := s.BatchCreate(context.Background(), &pb.BatchCreateRequest{Articles: []*pb.Article{
+		{AuthorId: testAuthor.Hex(), Title: "Book1"},
+		{AuthorId: testAuthor.Hex()},
+	}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	e := res.Results[1].GetError()
+	if codes.Code(e.GetCode()) != codes.InvalidArgument || !strings.Contains(e.GetMessage(), "title") {
+		t.Errorf("Expected title violation, got %v", res.Results[1])
+	}
+}
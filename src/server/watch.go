@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/repo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var eventTypes = map[repo.EventType]pb.WatchResponse_Type{
+	repo.EventCreated: pb.WatchResponse_CREATED,
+	repo.EventUpdated: pb.WatchResponse_UPDATED,
+	repo.EventDeleted: pb.WatchResponse_DELETED,
+}
+
+// Watch streams changes of Articles until the client goes away
+func (s *BlogServer) Watch(r *pb.WatchRequest, stream pb.Blog_WatchServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	out := make(chan repo.ArticleEvent)
+	e := make(chan error, 1)
+	go func() {
+		e <- s.r.WatchArticles(ctx, r.GetResumeToken(), out)
+	}()
+	for ev := range out {
+		a := ev.Article.ToPB()
+		if ev.Type == repo.EventDeleted && !ev.Article.IsDeleted() {
+			// purged Articles have nothing but ID
+			a = &pb.Article{Id: a.Id}
+		}
+		err := stream.Send(&pb.WatchResponse{
+			Type:        eventTypes[ev.Type],
+			Article:     a,
+			ResumeToken: ev.Token,
+		})
+		if err != nil {
+			cancel()
+			// let the repo finish, it closes out once it notices cancellation
+			for range out {
+			}
+			log.Printf("Got error while sending: %v", err)
+			return status.Error(codes.Internal, internalError)
+		}
+	}
+	err := <-e
+	switch {
+	case errors.Is(err, repo.ErrInvalidResumeToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repo.ErrWatcherBehind):
+		// the client may resume from the last token it got
+		return status.Error(codes.Aborted, err.Error())
+	case err != nil:
+		log.Printf("Error watching articles: %v\n", err)
+		return status.Error(codes.Internal, internalError)
+	}
+	if stream.Context().Err() == context.Canceled {
+		return status.Error(codes.Canceled, requestCancelled)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "example.com/grpc/blog/gen/src"
+	"example.com/grpc/blog/src/models"
+	"example.com/grpc/blog/src/repo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testWatchServer struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	events chan *pb.WatchResponse
+}
+
+func (s *testWatchServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testWatchServer) Send(m *pb.WatchResponse) error {
+	s.events <- m
+	return nil
+}
+
+// startWatch runs Watch until the returned cancel function is called
+func startWatch(s *BlogServer, token string) (*testWatchServer, chan error, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ts := &testWatchServer{ctx: ctx, events: make(chan *pb.WatchResponse, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Watch(&pb.WatchRequest{ResumeToken: token}, ts)
+	}()
+	return ts, done, cancel
+}
+
+func nextEvent(t *testing.T, ts *testWatchServer) *pb.WatchResponse {
+	select {
+	case e := <-ts.events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return nil
+}
+
+func TestWatch(t *testing.T) {
+	r := repo.NewMapRepo(make(map[primitive.ObjectID]models.Article))
+	s := NewBlogServer(r, testAuthors())
+	// the watcher subscribes asynchronously, the map repo numbers events from one,
+	// so watching after "0" replays the changes made before it subscribes
+	ts, done, cancel := startWatch(s, "0")
+
+	c, err := s.Create(context.Background(), &pb.CreateRequest{Article: &pb.Article{AuthorId: testAuthor.Hex(), Title: "Book1"}})
+	if err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := s.Update(context.Background(), &pb.UpdateRequest{Article: &pb.Article{Id: c.Article.Id, AuthorId: testAuthor.Hex(), Title: "Book1_updated"}}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+	if _, err := s.Delete(context.Background(), &pb.DeleteRequest{Id: c.Article.Id}); err != nil {
+		t.Fatalf("Got error: %v", err)
+	}
+
+	created := nextEvent(t, ts)
+	if created.Type != pb.WatchResponse_CREATED || created.Article.Title != "Book1" {
+		t.Fatalf("Wrong event: %v", created)
+	}
+	updated := nextEvent(t, ts)
+	if updated.Type != pb.WatchResponse_UPDATED || updated.Article.Title != "Book1_updated" {
+		t.Fatalf("Wrong event: %v", updated)
+	}
+	deleted := nextEvent(t, ts)
+	if deleted.Type != pb.WatchResponse_DELETED || deleted.Article.DeleteTime == nil {
+		t.Fatalf("Wrong event: %v", deleted)
+	}
+	cancel()
+	if se, _ := status.FromError(<-done); se.Code() != codes.Canceled {
+		t.Fatalf("Expected the watch to be cancelled, got %v", se)
+	}
+
+	// resuming replays events after the token
+	ts, done, cancel = startWatch(s, created.ResumeToken)
+	defer cancel()
+	if e := nextEvent(t, ts); e.ResumeToken != updated.ResumeToken {
+		t.Fatalf("Expected to resume from the update, got %v", e)
+	}
+	if e := nextEvent(t, ts); e.ResumeToken != deleted.ResumeToken {
+		t.Fatalf("Expected to resume with the deletion, got %v", e)
+	}
+}
+
+func TestWatch_invalid_token(t *testing.T) {
+	s := NewBlogServer(repo.NewMapRepo(make(map[primitive.ObjectID]models.Article)), testAuthors())
+	_, done, cancel := startWatch(s, "42")
+	defer cancel()
+	if se, _ := status.FromError(<-done); se.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected invalid token error, got %v", se)
+	}
+}
@@ -0,0 +1,87 @@
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the maximum length of a slug without collision suffix
+const MaxLength = 80
+
+// fallback is used when nothing is left of the title
+const fallback = "article"
+
+// translit covers letters which don't decompose into ASCII letter and diacritics
+var translit = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'ø': "o", 'œ': "oe", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Make turns the title into URL friendly slug of lowercase ASCII letters, digits and dashes
+func Make(title string) string {
+	if slug := words(title); slug != "" {
+		return slug
+	}
+	return fallback
+}
+
+// IsEmpty tells whether nothing of the title is left for the slug, Make falls back to a placeholder then
+func IsEmpty(title string) bool {
+	return words(title) == ""
+}
+
+// words joins letters and digits of the title with dashes, it's empty when there are none
+func words(title string) string {
+	b := strings.Builder{}
+	dash := false
+	for _, r := range norm.NFKD.String(strings.ToLower(title)) {
+		s, ok := translit[r]
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// diacritics left after decomposition
+			continue
+		case ok && s == "":
+			// signs without sound
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			s = string(r)
+		}
+		if s == "" {
+			dash = b.Len() > 0
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteString(s)
+	}
+	slug := b.String()
+	if len(slug) > MaxLength {
+		slug = slug[:MaxLength]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+		slug = strings.TrimRight(slug, "-")
+	}
+	return slug
+}
+
+// WithSuffix makes the n-th variant of the slug used to resolve collisions
+func WithSuffix(slug string, n int) string {
+	if n < 2 {
+		return slug
+	}
+	return slug + "-" + strconv.Itoa(n)
+}